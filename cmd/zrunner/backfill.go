@@ -0,0 +1,93 @@
+/*
+Copyright © 2024 Zettablock
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package zrunner
+
+import (
+	"fmt"
+
+	"github.com/Zettablock/zetta-go/internal/cli"
+
+	"github.com/spf13/cobra"
+)
+
+// backfillCmd represents the backfill command
+var backfillCmd = &cobra.Command{
+	Use:   "backfill [pipeline-name]",
+	Short: "Process a range of blocks again with a deployed pipeline",
+	Long: `backfill has a pipeline deployed on the hosted service process the blocks from --from to --to
+again, e.g. after fixing a handler, and follows its progress until it is done. The range must start
+at or after the source.startBlock the pipeline was deployed with.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		err := backfill(cmd, args[0])
+		cobra.CheckErr(err)
+	},
+}
+
+func init() {
+	backfillCmd.Flags().Int64("from", 0, "first block to process")
+	backfillCmd.Flags().Int64("to", 0, "last block to process (default: the last block the pipeline processed)")
+	backfillCmd.Flags().String("api-key", "", "Zettablock api key (default: apikey of project.yml)")
+	backfillCmd.Flags().Bool("detach", false, "return once the backfill is submitted, without following it")
+	backfillCmd.MarkFlagRequired("from")
+}
+
+func backfill(cmd *cobra.Command, name string) error {
+	from, err := cmd.Flags().GetInt64("from")
+	if err != nil {
+		return err
+	}
+	to, err := cmd.Flags().GetInt64("to")
+	if err != nil {
+		return err
+	}
+	detach, err := cmd.Flags().GetBool("detach")
+	if err != nil {
+		return err
+	}
+
+	if to != 0 && to < from {
+		return fmt.Errorf("--to %d is before --from %d", to, from)
+	}
+	config, err := cli.LoadProject(cmd)
+	if err != nil {
+		return err
+	}
+	client, err := cli.APIClient(cmd, &config)
+	if err != nil {
+		return err
+	}
+	pipeline, err := deployedPipeline(cmd.Context(), client, &config, name)
+	if err != nil {
+		return err
+	}
+	if from < pipeline.Source.StartBlock {
+		return fmt.Errorf("--from %d is before block %d, the source.startBlock of %s", from, pipeline.Source.StartBlock, name)
+	}
+
+	j, err := client.Backfill(cmd.Context(), config.Org, config.Name, name, from, to)
+	if err != nil {
+		return err
+	}
+	if j.Pipeline == "" {
+		j.Pipeline = name
+	}
+	fmt.Printf("Backfill of %s submitted.\n", name)
+	if detach {
+		return nil
+	}
+	return followJob(cmd.Context(), client, j)
+}
@@ -0,0 +1,120 @@
+/*
+Copyright © 2024 Zettablock
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package zrunner
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Zettablock/zetta-go/internal"
+	"github.com/Zettablock/zetta-go/internal/cli"
+	"github.com/Zettablock/zetta-go/internal/compat"
+	"github.com/Zettablock/zetta-go/internal/gomod"
+	"github.com/Zettablock/zetta-go/internal/runner"
+
+	"github.com/spf13/cobra"
+)
+
+// buildCmd represents the build command
+var buildCmd = &cobra.Command{
+	Use:   "build [pipeline-name]...",
+	Short: "Check that the pipelines compile",
+	Long: `build compiles every pipeline (default: all) as a Go plugin, the way the hosted service does,
+against the zsource version pinned in go.mod. go.mod and go.sum are not modified, so they must
+already list every dependency; run "go mod tidy" otherwise.
+
+Compiler errors are reported per pipeline. deploy runs the same check before submitting.
+In a workspace, every project is built unless one is chosen with --project or pipelines are named.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := buildProjects(cmd, args)
+		cobra.CheckErr(err)
+	},
+}
+
+func init() {
+}
+
+func buildProjects(cmd *cobra.Command, names []string) error {
+	var configs []internal.ProjectConfig
+	var err error
+	if len(names) > 0 {
+		config, err := cli.LoadProject(cmd)
+		if err != nil {
+			return err
+		}
+		configs = append(configs, config)
+	} else if configs, err = cli.LoadProjects(cmd); err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, config := range configs {
+		if len(configs) > 1 {
+			fmt.Printf("Project %s:\n", config.Name)
+		}
+		pipelines, err := config.SelectPipelines(names)
+		if err != nil {
+			return err
+		}
+		zsource, err := gomod.ZSource(config.Root)
+		if err != nil {
+			return err
+		}
+		if err = checkCompatibility(cmd, compat.Embedded(), zsource, false); err != nil {
+			return err
+		}
+		err = checkPipelines(config.Root, pipelines)
+		if len(configs) == 1 {
+			return err
+		}
+		if err != nil {
+			fmt.Println(err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d projects do not build", failed, len(configs))
+	}
+	return nil
+}
+
+// checkPipelines compiles every pipeline of the project at root and reports
+// the errors of each.
+func checkPipelines(root string, pipelines []internal.PipelineConfig) error {
+	if len(pipelines) == 0 {
+		return errors.New("no pipeline found")
+	}
+
+	failed := 0
+	for _, pipeline := range pipelines {
+		err := runner.CheckPlugin(root, pipeline)
+		var buildErr *runner.BuildError
+		if errors.As(err, &buildErr) {
+			fmt.Println(buildErr)
+			failed++
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s builds.\n", pipeline.Name)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d pipelines do not build", failed, len(pipelines))
+	}
+	return nil
+}
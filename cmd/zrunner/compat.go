@@ -0,0 +1,65 @@
+/*
+Copyright © 2024 Zettablock
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package zrunner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/Zettablock/zetta-go/internal/api"
+	"github.com/Zettablock/zetta-go/internal/compat"
+	"github.com/Zettablock/zetta-go/internal/gomod"
+
+	"github.com/spf13/cobra"
+)
+
+// compatibilityMatrix returns the compatibility matrix of the service if an
+// api key is given and it can be fetched, or else the one embedded in the CLI.
+func compatibilityMatrix(ctx context.Context, apiKey string) compat.Matrix {
+	if apiKey != "" {
+		m, err := api.New(apiKey).Compatibility(ctx)
+		if err == nil && len(m) > 0 {
+			return m
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: using the embedded compatibility matrix, fetching it failed: %v\n", err)
+		}
+	}
+	return compat.Embedded()
+}
+
+// checkCompatibility checks that the CLI supports the zsource version required
+// by go.mod, or the release a pseudo-version derives from. An unsupported
+// version is an error if strict is set, and only a warning otherwise.
+func checkCompatibility(cmd *cobra.Command, matrix compat.Matrix, zsource *gomod.Requirement, strict bool) error {
+	cliVersion := cmd.Root().Version
+	if cliVersion == "" {
+		return nil
+	}
+
+	err := matrix.Check(cliVersion, zsource.Base)
+	var incompatible *compat.IncompatibleError
+	if !errors.As(err, &incompatible) {
+		return err
+	}
+	if !strict {
+		fmt.Fprintf(os.Stderr, "Warning: %v, run `zetta-go zrunner upgrade-zsource`.\n", err)
+		return nil
+	}
+	return fmt.Errorf("%w, run `zetta-go zrunner upgrade-zsource`", err)
+}
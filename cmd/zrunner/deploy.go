@@ -16,74 +16,88 @@ limitations under the License.
 package zrunner
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
-	"io/fs"
-	"io/ioutil"
-	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
+
+	"github.com/Zettablock/zetta-go/internal"
+	"github.com/Zettablock/zetta-go/internal/api"
+	"github.com/Zettablock/zetta-go/internal/cli"
+	"github.com/Zettablock/zetta-go/internal/compat"
+	"github.com/Zettablock/zetta-go/internal/git"
+	"github.com/Zettablock/zetta-go/internal/gomod"
+	"github.com/Zettablock/zetta-go/internal/localdb"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/spf13/cobra"
-	"golang.org/x/mod/modfile"
-	"gopkg.in/yaml.v3"
 )
 
 const (
-	qugateEndpoint = "https://api.zettablock.com/api/v1/zrunner/pipeline"
-	goModFile      = "go.mod"
-	zsourceModule  = "github.com/Zettablock/zsource"
-	pipelineYml    = "pipeline.yml"
-	projectYml     = "project.yml"
+	goModFile = "go.mod"
 )
 
 type Payload struct {
-	Org            string            `json:"org"`
-	Project        string            `json:"project"`
+	Org     string `json:"org"`
+	Project string `json:"project"`
+	// Path is the folder of the project in the repository, empty if it is
+	// the repository root.
+	Path           string            `json:"path,omitempty"`
 	ApiKey         string            `json:"api_key"`
 	GithubRepo     string            `json:"github_repo"`
 	Pat            string            `json:"pat"`
 	Pipelines      []PipelinePayload `json:"pipelines"`
 	ZSourceVersion string            `json:"zsource_version"`
-	Version        string            `json:"version"`
+	ZSourceCommit  string            `json:"zsource_commit,omitempty"`
+	// Changes are the pipelines added, updated and removed, compared to those
+	// deployed. Pipelines holds the added and updated ones.
+	Changes PipelineChanges `json:"changes"`
+	// Commit is the commit of the repository to deploy, and Ref the branch,
+	// tag or commit it was named by.
+	Commit  string `json:"commit,omitempty"`
+	Ref     string `json:"ref,omitempty"`
+	Version string `json:"version"`
 }
 
 type PipelinePayload struct {
-	Name string `json:"name"`
-}
-
-type ProjectConfig struct {
-	Dir            string
-	Org            string
-	Kind           string
-	Network        string
-	Version        string
-	Name           string
-	ApiKey         string
-	GithubRepo     string `yaml:"githubRepo"`
-	Pat            string
-	ZSourceVersion string
-	Pipelines      []PipelineConfig
+	Name  string                `json:"name"`
+	Retry *internal.RetryConfig `json:"retry,omitempty"`
+	// Env holds plain values, and the names of the secrets of the others.
+	Env map[string]internal.EnvValue `json:"env,omitempty"`
 }
 
-type PipelineConfig struct {
-	Name string
-	Dir  string
+type PipelineChanges struct {
+	Add    []string `json:"add"`
+	Update []string `json:"update"`
+	Remove []string `json:"remove"`
 }
 
 // deployCmd represents the deploy command
 var deployCmd = &cobra.Command{
 	Use:   "deploy",
 	Short: "Deploy the project to the hosted zrunner service",
+	Long: `deploy submits the project to the hosted zrunner service. In a workspace, it deploys the project
+the current folder is in, the one chosen with --project, or else every project of the workspace.
+Every project is validated and built before any is submitted.
+
+The deployment is pinned to the commit checked out, or to --ref. It must be pushed, and the project
+must not have uncommitted changes, unless --allow-dirty is set.
+
+With --pipeline, only the named pipelines are deployed. Otherwise, deployed pipelines the project no
+longer has are removed.
+
+A version the service already has is refused. --bump increments the version of project.yml, commits
+it and pushes the commit; --tag tags the deployed commit with the version, and pushes the tag.
+
+With --wait, deploy follows each deployment as it is cloned, built, migrated and started, and exits
+with an error if it fails or is not running within --timeout.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		err := deployProject(cmd)
 		cobra.CheckErr(err)
-		fmt.Println("Deployment submitted.")
 	},
 }
 
@@ -99,101 +113,373 @@ func init() {
 	// is called directly, e.g.:
 	// deployCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 
-	deployCmd.Flags().String("api-key", "", "Zettablock api key")
+	deployCmd.Flags().String("api-key", "", "Zettablock api key (default: apikey of project.yml)")
 	deployCmd.Flags().String("pat", "", "github repo personal access token, necessary if the repo is private")
-	deployCmd.MarkFlagRequired("api-key")
+	deployCmd.Flags().StringSlice("pipeline", nil, "pipelines to deploy, leaving the other deployed pipelines as they are (default: all)")
+	deployCmd.Flags().Bool("skip-build", false, "do not check that the pipelines compile before deploying")
+	deployCmd.Flags().Bool("allow-dirty", false, "deploy even with uncommitted changes or unpushed commits")
+	deployCmd.Flags().String("ref", "", "branch, tag or commit to deploy (default: the commit checked out)")
+	deployCmd.Flags().String("bump", "", "increment the version of project.yml, patch, minor or major, and commit it")
+	deployCmd.Flags().Bool("tag", false, "tag the deployed commit with the version")
+	deployCmd.MarkFlagsMutuallyExclusive("bump", "ref")
+	deployCmd.Flags().Bool("wait", false, "wait until the deployment runs, and fail if it does not")
+	deployCmd.Flags().Duration("timeout", 15*time.Minute, "how long --wait waits, 0 for no limit")
 }
 
 func deployProject(cmd *cobra.Command) error {
-	apiKey, err := cmd.Flags().GetString("api-key")
+	pat, err := cmd.Flags().GetString("pat")
 	if err != nil {
 		return err
 	}
-	if apiKey == "" {
-		return errors.New("api-key is required")
+	skipBuild, err := cmd.Flags().GetBool("skip-build")
+	if err != nil {
+		return err
 	}
-	pat, err := cmd.Flags().GetString("pat")
+	wait, err := cmd.Flags().GetBool("wait")
 	if err != nil {
 		return err
 	}
-
-	payload, err := generatePayload()
+	timeout, err := cmd.Flags().GetDuration("timeout")
 	if err != nil {
 		return err
 	}
-	payload.ApiKey = apiKey
-	payload.Pat = pat
-
-	b, err := json.Marshal(payload)
+	allowDirty, err := cmd.Flags().GetBool("allow-dirty")
 	if err != nil {
 		return err
 	}
-
-	req, err := http.NewRequest("POST", qugateEndpoint, bytes.NewBuffer(b))
+	ref, err := cmd.Flags().GetString("ref")
+	if err != nil {
+		return err
+	}
+	names, err := cmd.Flags().GetStringSlice("pipeline")
+	if err != nil {
+		return err
+	}
+	bump, err := cmd.Flags().GetString("bump")
+	if err != nil {
+		return err
+	}
+	tag, err := cmd.Flags().GetBool("tag")
 	if err != nil {
 		return err
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-API-KEY", apiKey)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	configs, err := cli.LoadProjects(cmd)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
+	configs, selected, err := selectDeployed(configs, names)
+	if err != nil {
+		return err
+	}
+	apiKeys := make([]string, len(configs))
+	for i := range configs {
+		if apiKeys[i], err = cli.APIKey(cmd, &configs[i]); err != nil {
+			return projectError(configs, i, err)
+		}
+	}
+	matrix := compatibilityMatrix(cmd.Context(), apiKeys[0])
+
+	// check every project before changing or submitting any
+	payloads := make([]*Payload, 0, len(configs))
+	for i := range configs {
+		client := api.New(apiKeys[i])
+		// the git and version checks are cheaper than building, so they
+		// come first
+		commit, name, err := pinCommit(configs[i].Root, ref, allowDirty)
+		version := ""
+		if err == nil {
+			version, err = newVersion(cmd.Context(), client, &configs[i], bump)
+		}
+		var payload *Payload
+		if err == nil {
+			payload, err = prepareDeployment(cmd, &configs[i], selected[i], matrix, skipBuild)
+		}
+		var remote []api.RemotePipeline
+		if err == nil {
+			remote, err = client.Pipelines(cmd.Context(), configs[i].Org, configs[i].Name)
+		}
+		if err == nil {
+			err = checkSecrets(cmd.Context(), client, &configs[i], selected[i])
+		}
+		if err != nil {
+			return projectError(configs, i, err)
+		}
+		payload.Version = version
+		payload.Changes = planChanges(remote, selected[i], len(names) == 0)
+		payload.Commit = commit
+		payload.Ref = name
+		payload.ApiKey = apiKeys[i]
+		payload.Pat = pat
+		payloads = append(payloads, payload)
+	}
+
+	if bump != "" || tag {
+		for i, payload := range payloads {
+			if err = release(&configs[i], payload, bump != "", tag, allowDirty); err != nil {
+				return projectError(configs, i, err)
+			}
+		}
+	}
 
-	if resp.StatusCode != http.StatusOK {
-		body, err := ioutil.ReadAll(resp.Body)
+	deployments := make([]*api.Deployment, 0, len(payloads))
+	for _, payload := range payloads {
+		fmt.Printf("Deploying %s %s: %s.\n", payload.Project, payload.Version, payload.Changes)
+		d, err := api.New(payload.ApiKey).Deploy(cmd.Context(), payload, wait)
 		if err != nil {
+			return fmt.Errorf("project %s: %w", payload.Project, err)
+		}
+		if d.Project == "" {
+			d.Project = payload.Project
+		}
+		deployments = append(deployments, d)
+		if wait && d.ID == "" {
+			fmt.Printf("Deployment of %s submitted, no deployment info.\n", payload.Project)
+		} else {
+			fmt.Printf("Deployment of %s submitted.\n", payload.Project)
+		}
+	}
+
+	if !wait {
+		return nil
+	}
+	for i, d := range deployments {
+		if err = waitForDeployment(cmd.Context(), api.New(payloads[i].ApiKey), d, timeout); err != nil {
 			return err
 		}
-		return fmt.Errorf("request failed: %s", string(body))
 	}
+	return nil
+}
 
+// projectError prefixes err with the name of the i-th project, if there are
+// several.
+func projectError(configs []internal.ProjectConfig, i int, err error) error {
+	if len(configs) > 1 {
+		return fmt.Errorf("project %s: %w", configs[i].Name, err)
+	}
 	return err
 }
 
-func generatePayload() (*Payload, error) {
-	var err error
-	var pipelines []PipelinePayload
+// selectDeployed returns the projects with pipelines to deploy, and those
+// pipelines: every one, or those named, which may belong to any project.
+func selectDeployed(configs []internal.ProjectConfig, names []string) ([]internal.ProjectConfig, [][]internal.PipelineConfig, error) {
+	if len(names) == 0 {
+		selected := make([][]internal.PipelineConfig, len(configs))
+		for i := range configs {
+			selected[i] = configs[i].Pipelines
+		}
+		return configs, selected, nil
+	}
+
+	var kept []internal.ProjectConfig
+	var selected [][]internal.PipelineConfig
+	found := make(map[string]bool)
+	for _, config := range configs {
+		var pipelines []internal.PipelineConfig
+		for _, name := range names {
+			if pipeline, err := config.Pipeline(name); err == nil {
+				pipelines = append(pipelines, pipeline)
+				found[name] = true
+			}
+		}
+		if len(pipelines) > 0 {
+			kept = append(kept, config)
+			selected = append(selected, pipelines)
+		}
+	}
+	for _, name := range names {
+		if !found[name] {
+			return nil, nil, fmt.Errorf("pipeline %s not found", name)
+		}
+	}
+	return kept, selected, nil
+}
 
-	payload := &Payload{}
-	config, err := collectProjectInfo()
+// checkSecrets checks that the secrets the pipelines reference are set.
+func checkSecrets(ctx context.Context, client *api.Client, config *internal.ProjectConfig, pipelines []internal.PipelineConfig) error {
+	for _, pipeline := range pipelines {
+		referenced := pipeline.Secrets()
+		if len(referenced) == 0 {
+			continue
+		}
+		secrets, err := client.Secrets(ctx, config.Org, config.Name, pipeline.Name)
+		if err != nil {
+			return err
+		}
+		set := make(map[string]bool, len(secrets))
+		for _, s := range secrets {
+			set[s.Name] = true
+		}
+		for _, name := range referenced {
+			if !set[name] {
+				return fmt.Errorf("secret %s of pipeline %s is not set, set it with zetta-go zrunner secrets set %s %s", name, pipeline.Name, pipeline.Name, name)
+			}
+		}
+	}
+	return nil
+}
+
+// planChanges compares the pipelines to deploy with those deployed. A full
+// deployment also removes the deployed pipelines the project no longer has.
+func planChanges(remote []api.RemotePipeline, pipelines []internal.PipelineConfig, full bool) PipelineChanges {
+	deployed := make(map[string]bool, len(remote))
+	for _, p := range remote {
+		deployed[p.Name] = true
+	}
+	changes := PipelineChanges{Add: []string{}, Update: []string{}, Remove: []string{}}
+	kept := make(map[string]bool, len(pipelines))
+	for _, p := range pipelines {
+		kept[p.Name] = true
+		if deployed[p.Name] {
+			changes.Update = append(changes.Update, p.Name)
+		} else {
+			changes.Add = append(changes.Add, p.Name)
+		}
+	}
+	if full {
+		for _, p := range remote {
+			if !kept[p.Name] {
+				changes.Remove = append(changes.Remove, p.Name)
+			}
+		}
+	}
+	return changes
+}
+
+func (c PipelineChanges) String() string {
+	var parts []string
+	for _, change := range []struct {
+		verb  string
+		names []string
+	}{{"add", c.Add}, {"update", c.Update}, {"remove", c.Remove}} {
+		if len(change.names) > 0 {
+			parts = append(parts, change.verb+" "+strings.Join(change.names, ", "))
+		}
+	}
+	if len(parts) == 0 {
+		return "no pipeline"
+	}
+	return strings.Join(parts, "; ")
+}
+
+// prepareDeployment validates a project and builds the pipelines to deploy,
+// and returns its payload.
+func prepareDeployment(cmd *cobra.Command, config *internal.ProjectConfig, pipelines []internal.PipelineConfig, matrix compat.Matrix, skipBuild bool) (*Payload, error) {
+	zsource, err := gomod.ZSource(config.Root)
 	if err != nil {
 		return nil, err
 	}
+	if err = zsource.Strict(); err != nil {
+		return nil, err
+	}
+	if zsource.Replace != nil {
+		fmt.Fprintf(os.Stderr, "Warning: zsource is replaced with %s.\n", zsource.Replace)
+	}
+
+	payload, err := generatePayload(config, pipelines, zsource)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = checkCompatibility(cmd, matrix, zsource, true); err != nil {
+		return nil, err
+	}
+
+	if !skipBuild {
+		if err = checkPipelines(config.Root, pipelines); err != nil {
+			return nil, fmt.Errorf("%w, deployment cancelled", err)
+		}
+	}
+	return payload, nil
+}
 
-	zsourceVer, err := zsourceVersion()
+// pinCommit returns the commit to deploy the project at root from, and the
+// ref naming it: ref if set, or else the commit checked out and its branch.
+// Unless allowDirty is set, the commit must be pushed, and the project must
+// not have uncommitted changes when the commit is checked out.
+func pinCommit(root, ref string, allowDirty bool) (string, string, error) {
+	head, err := git.ReadHead(root)
+	if errors.Is(err, git.ErrNotRepository) && allowDirty && ref == "" {
+		fmt.Fprintln(os.Stderr, "Warning: the project is not in a git repository, the service deploys the default branch.")
+		return "", "", nil
+	}
+	if errors.Is(err, git.ErrNotRepository) && ref == "" {
+		return "", "", fmt.Errorf("%w, use --allow-dirty to deploy the default branch anyway", err)
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	commit, name := head.Commit, head.Branch
+	if ref != "" {
+		if commit, err = git.Resolve(root, ref); err != nil {
+			return "", "", err
+		}
+		name = ref
+	}
+	if commit != head.Commit {
+		fmt.Fprintf(os.Stderr, "Warning: the pipelines were validated from the working tree, not from commit %s.\n", ref)
+	} else if !allowDirty {
+		// local runs and builds write under .zrunner, which is not deployed
+		changes, err := git.Changes(root, localdb.StateDir)
+		if err != nil {
+			return "", "", err
+		}
+		if len(changes) > 0 {
+			return "", "", fmt.Errorf("the project has uncommitted changes, commit them or use --allow-dirty:\n  %s", strings.Join(changes, "\n  "))
+		}
+	}
+
+	if !allowDirty {
+		pushed, err := git.Pushed(root, commit)
+		if err != nil {
+			return "", "", err
+		}
+		if !pushed {
+			return "", "", fmt.Errorf("commit %.12s is not pushed, so the service cannot fetch it; push it or use --allow-dirty", commit)
+		}
+	}
+	return commit, name, nil
+}
+
+func generatePayload(config *internal.ProjectConfig, deployed []internal.PipelineConfig, zsource *gomod.Requirement) (*Payload, error) {
+	var err error
+	var pipelines []PipelinePayload
+
+	payload := &Payload{}
+	zsourceVer, err := semver.NewVersion(zsource.Version)
 	if err != nil {
 		return nil, err
 	}
-	config.ZSourceVersion = zsourceVer
+	config.ZSourceVersion = zsourceVer.String()
 
-	err = validateConfig(&config)
+	err = validateConfig(config)
 	if err != nil {
 		return nil, err
 	}
 
 	payload.Project = config.Name
+	payload.Path = config.Path
 	payload.Org = config.Org
 	payload.Version = config.Version
 	payload.GithubRepo = config.GithubRepo
 	payload.ZSourceVersion = config.ZSourceVersion
+	payload.ZSourceCommit = zsource.Commit
 
-	for _, pipelineCfg := range config.Pipelines {
-		pipelines = append(pipelines, PipelinePayload{pipelineCfg.Name})
+	for _, pipelineCfg := range deployed {
+		pipelines = append(pipelines, PipelinePayload{pipelineCfg.Name, pipelineCfg.Retry, pipelineCfg.Env})
 	}
 	payload.Pipelines = pipelines
 
 	return payload, nil
 }
 
-func validateConfig(config *ProjectConfig) error {
+func validateConfig(config *internal.ProjectConfig) error {
 	if config.Name == "" {
 		return errors.New("project name should not be empty")
 	}
-	if config.Name != config.Dir {
+	// the service finds a project of a workspace by its path instead
+	if config.Workspace == "" && config.Name != config.Dir {
 		return fmt.Errorf("project name: %s should be the same as the project folder name: %s", config.Name, config.Dir)
 	}
 	if config.Org == "" {
@@ -242,132 +528,20 @@ func validateConfig(config *ProjectConfig) error {
 		if pipeline.Name == "" {
 			return errors.New("pipeline name should not be empty")
 		}
-		if pipeline.Dir != pipeline.Name {
-			return fmt.Errorf("pipeline name: %s should be the same as the pipeline folder name: %s", pipeline.Name, pipeline.Dir)
+		if filepath.Base(pipeline.Dir) != pipeline.Name {
+			return fmt.Errorf("pipeline name: %s should be the same as the pipeline folder name: %s", pipeline.Name, filepath.Base(pipeline.Dir))
 		}
-	}
-	return nil
-}
-
-func collectProjectInfo() (ProjectConfig, error) {
-	projectCfg := ProjectConfig{}
-	projectCfgLoc, err := findProjectConfig()
-	if err != nil {
-		return projectCfg, err
-	}
-
-	projectCfg.Dir = filepath.Base(filepath.Dir(projectCfgLoc))
-
-	data, err := os.ReadFile(projectCfgLoc)
-	if err != nil {
-		return projectCfg, err
-	}
-	err = yaml.Unmarshal(data, &projectCfg)
-	if err != nil {
-		return ProjectConfig{}, err
-	}
-
-	pipelineCfgs, err := findPipelineConfig()
-
-	if len(pipelineCfgs) == 0 || err != nil {
-		return projectCfg, err
-	}
-
-	for _, cfgLoc := range pipelineCfgs {
-		cfg := PipelineConfig{}
-		data, err = os.ReadFile(cfgLoc)
-		if err != nil {
-			return projectCfg, err
-		}
-
-		err = yaml.Unmarshal(data, &cfg)
-		if err != nil {
-			return projectCfg, err
-		}
-
-		cfg.Dir = filepath.Base(filepath.Dir(cfgLoc))
-		projectCfg.Pipelines = append(projectCfg.Pipelines, cfg)
-	}
-
-	return projectCfg, nil
-}
-
-func findProjectConfig() (string, error) {
-	var projectConfigLoc string
-	err := filepath.Walk(".", func(path string, info fs.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if !info.IsDir() && info.Name() == projectYml {
-			absPath, err := filepath.Abs(path)
-			if err != nil {
-				return err
-			}
-
-			projectConfigLoc = absPath
-			return filepath.SkipDir // Stop walking after finding the file
-		}
-		return nil
-	})
-	if err != nil {
-		return projectConfigLoc, err
-	}
-
-	return projectConfigLoc, nil
-}
-
-// TODO: For now we support run in project folder only. Can extend to support path parameter.
-func findPipelineConfig() ([]string, error) {
-	var configFiles []string
-	err := filepath.Walk(".", func(path string, info fs.FileInfo, err error) error {
-		if err != nil {
-			return err
+		if pipeline.Parallelism < 0 {
+			return fmt.Errorf("pipeline %s: parallelism should not be negative", pipeline.Name)
 		}
-		if !info.IsDir() && info.Name() == pipelineYml {
-			absPath, err := filepath.Abs(path)
-			if err != nil {
-				return err
+		if pipeline.Retry != nil {
+			if _, err := pipeline.Retry.Policy(); err != nil {
+				return fmt.Errorf("pipeline %s: %w", pipeline.Name, err)
 			}
-
-			configFiles = append(configFiles, absPath)
-			return filepath.SkipDir // Stop walking after finding the file
 		}
-		return nil
-	})
-	if err != nil {
-		return configFiles, err
-	}
-
-	return configFiles, nil
-}
-
-func zsourceVersion() (string, error) {
-	data, err := os.ReadFile(goModFile)
-	if err != nil {
-		return "", err
-	}
-
-	f, err := modfile.Parse(goModFile, data, nil)
-	if err != nil {
-		return "", err
-	}
-
-	moduleVersion := ""
-	for _, req := range f.Require {
-		if req.Mod.Path == zsourceModule {
-			moduleVersion = req.Mod.Version
-			break
+		if err := pipeline.CheckEnv(); err != nil {
+			return fmt.Errorf("pipeline %s: %w", pipeline.Name, err)
 		}
 	}
-
-	if moduleVersion == "" {
-		return "", errors.New("zsource module not found in go.mod file")
-	}
-
-	v, err := semver.NewVersion(moduleVersion)
-	if err != nil {
-		return "", err
-	}
-
-	return v.String(), nil
+	return nil
 }
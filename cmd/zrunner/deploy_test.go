@@ -0,0 +1,136 @@
+package zrunner
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/Zettablock/zetta-go/internal/api"
+)
+
+// fakeService records the deployments submitted to it, and lists them as
+// the deployed versions.
+type fakeService struct {
+	mu          sync.Mutex
+	deployments []Payload
+}
+
+func (s *fakeService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/versions":
+		versions := []api.Version{}
+		for _, d := range s.deployments {
+			versions = append(versions, api.Version{Version: d.Version, Commit: d.Commit})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"versions": versions})
+	case r.Method == http.MethodGet && r.URL.Path == "/pipelines":
+		w.Write([]byte(`{"pipelines": []}`))
+	case r.Method == http.MethodPost && r.URL.Path == "/pipeline":
+		var p Payload
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.deployments = append(s.deployments, p)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// newDeployedRepo creates the demo project in a git repository whose
+// branch is pushed to a bare origin, and returns the project folder.
+func newDeployedRepo(t *testing.T) string {
+	t.Helper()
+	tmp := t.TempDir()
+	root := filepath.Join(tmp, "demo")
+	files := map[string]string{
+		"project.yml":            "org: acme\nkind: ethereum\nnetwork: mainnet\nversion: 0.1.0\nname: demo\ngithubRepo: https://github.com/acme/demo\n",
+		"go.mod":                 "module demo\n\ngo 1.21\n\nrequire github.com/Zettablock/zsource v0.2.0\n",
+		".gitignore":             ".env\n",
+		"transfers/pipeline.yml": "name: transfers\nsource:\n  startBlock: 1\nblockHandlers:\n  - handler: HandleBlock\n",
+	}
+	for name, data := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	gitRun(t, tmp, "init", "--quiet", "--bare", "origin.git")
+	gitRun(t, root, "init", "--quiet", "--initial-branch", "main")
+	gitRun(t, root, "config", "user.email", "dev@acme.test")
+	gitRun(t, root, "config", "user.name", "dev")
+	gitRun(t, root, "add", ".")
+	gitRun(t, root, "commit", "--quiet", "--message", "demo")
+	gitRun(t, root, "remote", "add", "origin", filepath.Join(tmp, "origin.git"))
+	gitRun(t, root, "push", "--quiet", "--set-upstream", "origin", "main")
+	return root
+}
+
+func gitRun(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+// TestDeployTwice deploys a project twice in a row, with what a local run
+// or build leaves under .zrunner in between, which is not a change to
+// commit.
+func TestDeployTwice(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	root := newDeployedRepo(t)
+	service := &fakeService{}
+	srv := httptest.NewServer(service)
+	defer srv.Close()
+	t.Setenv("ZRUNNER_API_URL", srv.URL)
+
+	deployCmd.SetContext(context.Background())
+	args := []string{"--project-dir", root, "--api-key", "key", "--skip-build", "--bump", "patch"}
+	for i := 0; i < 2; i++ {
+		if err := deployCmd.ParseFlags(args); err != nil {
+			t.Fatal(err)
+		}
+		if err := deployProject(deployCmd); err != nil {
+			t.Fatalf("deployment %d: %v", i+1, err)
+		}
+
+		for _, name := range []string{"build/transfers.so", "state/transfers.json"} {
+			path := filepath.Join(root, ".zrunner", name)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte("local"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	if len(service.deployments) != 2 {
+		t.Fatalf("got %d deployments, want 2", len(service.deployments))
+	}
+	for i, want := range []string{"0.1.1", "0.1.2"} {
+		d := service.deployments[i]
+		if d.Version != want || d.Commit == "" || d.Ref != "main" {
+			t.Errorf("deployment %d: version %s, commit %q, ref %q, want version %s of main", i+1, d.Version, d.Commit, d.Ref, want)
+		}
+	}
+	if service.deployments[0].Commit == service.deployments[1].Commit {
+		t.Errorf("both deployments are of commit %s, want the commit of each bump", service.deployments[0].Commit)
+	}
+}
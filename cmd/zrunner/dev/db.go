@@ -0,0 +1,179 @@
+/*
+Copyright © 2024 Zettablock
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package dev
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/Zettablock/zetta-go/internal/cli"
+	"github.com/Zettablock/zetta-go/internal/fixtures"
+	"github.com/Zettablock/zetta-go/internal/localdb"
+
+	"github.com/spf13/cobra"
+)
+
+const schemasDir = "schemas"
+
+var (
+	dbCmd = &cobra.Command{
+		Use:   "db [command]",
+		Short: "Manage the local Postgres database used by local runs",
+		Args:  cobra.ExactArgs(1),
+	}
+
+	dbUpCmd = &cobra.Command{
+		Use:   "up",
+		Short: "Start the local database and apply the project schemas",
+		Long: `up starts an embedded Postgres server for the project and keeps it running in the background.
+
+The tables of schemas/*.sql are created under the org schema, which is what handlers get as deps.DestinationDB.
+Source tables are loaded from --fixtures into a schema named after the project kind, which is what
+handlers get as deps.SourceDB. Blocks, transactions and logs go to the tables of the matching zsource DAO types.
+
+The connection is recorded in .zrunner/ so local runs connect to it automatically.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			inst, err := dbUp(cmd)
+			cobra.CheckErr(err)
+			fmt.Println("Local database is running.")
+			fmt.Println("  source:     ", inst.SourceDSN())
+			fmt.Println("  destination:", inst.DestinationDSN())
+		},
+	}
+
+	dbEnvCmd = &cobra.Command{
+		Use:   "env",
+		Short: "Print the connection strings of the local database as environment variables",
+		Long: `env prints the connection strings of the local database as shell exports, for tools such as
+the generated handler tests:
+
+  eval $(zetta-go zrunner dev db env)`,
+		Run: func(cmd *cobra.Command, args []string) {
+			root, err := cli.ProjectRoot(cmd)
+			cobra.CheckErr(err)
+			inst, err := localdb.Lookup(root)
+			cobra.CheckErr(err)
+			fmt.Printf("export ZRUNNER_SOURCE_DSN=%q\n", inst.SourceDSN())
+			fmt.Printf("export ZRUNNER_DESTINATION_DSN=%q\n", inst.DestinationDSN())
+		},
+	}
+
+	dbDownCmd = &cobra.Command{
+		Use:   "down",
+		Short: "Stop the local database",
+		Run: func(cmd *cobra.Command, args []string) {
+			purge, err := cmd.Flags().GetBool("purge")
+			cobra.CheckErr(err)
+			root, err := cli.ProjectRoot(cmd)
+			cobra.CheckErr(err)
+			cobra.CheckErr(localdb.Down(root, purge))
+			fmt.Println("Local database stopped.")
+		},
+	}
+)
+
+func init() {
+	dbCmd.AddCommand(dbUpCmd)
+	dbCmd.AddCommand(dbEnvCmd)
+	dbCmd.AddCommand(dbDownCmd)
+
+	dbUpCmd.Flags().Uint32("port", localdb.DefaultPort, "port the local database listens on")
+	dbUpCmd.Flags().StringSlice("fixtures", nil, "fixture files or folders to load as source tables")
+	dbUpCmd.Flags().Bool("reset", false, "drop and recreate the org and source schemas")
+
+	dbDownCmd.Flags().Bool("purge", false, "also delete the database files under .zrunner/")
+}
+
+func dbUp(cmd *cobra.Command) (*localdb.Instance, error) {
+	port, err := cmd.Flags().GetUint32("port")
+	if err != nil {
+		return nil, err
+	}
+	fixturePaths, err := cmd.Flags().GetStringSlice("fixtures")
+	if err != nil {
+		return nil, err
+	}
+	reset, err := cmd.Flags().GetBool("reset")
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := cli.LoadProject(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if config.Org == "" {
+		return nil, errors.New("org should not be empty")
+	}
+	if config.Kind == "" {
+		return nil, errors.New("kind should not be empty")
+	}
+
+	inst := &localdb.Instance{
+		Port:              port,
+		Username:          localdb.DefaultUsername,
+		Password:          localdb.DefaultPassword,
+		Database:          localdb.DefaultDatabase,
+		SourceSchema:      config.Kind,
+		DestinationSchema: config.Org,
+	}
+	if err = localdb.Up(config.Root, inst, cmd.ErrOrStderr()); err != nil {
+		return nil, err
+	}
+
+	db, err := inst.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	exists, err := localdb.SchemaExists(db, inst.DestinationSchema)
+	if err != nil {
+		return nil, err
+	}
+	if reset || !exists {
+		if err = localdb.ResetSchema(db, inst.DestinationSchema); err != nil {
+			return nil, err
+		}
+		files, err := localdb.ApplySchemas(db, inst.DestinationSchema, filepath.Join(config.Root, schemasDir))
+		if err != nil {
+			return nil, err
+		}
+		fmt.Printf("Applied %d schema files under %s.\n", len(files), inst.DestinationSchema)
+	}
+
+	if reset {
+		if err = localdb.ResetSchema(db, inst.SourceSchema); err != nil {
+			return nil, err
+		}
+	}
+	if len(fixturePaths) > 0 {
+		records, err := fixtures.ReadAll(fixturePaths)
+		if err != nil {
+			return nil, err
+		}
+		counts, err := fixtures.Load(db, inst.SourceSchema, records)
+		if err != nil {
+			return nil, err
+		}
+		for table, count := range counts {
+			fmt.Printf("Loaded %d rows into %s.%s.\n", count, inst.SourceSchema, table)
+		}
+	}
+
+	return inst, nil
+}
@@ -0,0 +1,449 @@
+/*
+Copyright © 2024 Zettablock
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package zrunner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/Zettablock/zetta-go/internal"
+	"github.com/Zettablock/zetta-go/internal/api"
+	"github.com/Zettablock/zetta-go/internal/cli"
+	"github.com/Zettablock/zetta-go/internal/git"
+	"github.com/Zettablock/zetta-go/internal/gomod"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/spf13/cobra"
+)
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Show what deploying the project would change",
+	Long: `diff compares the local project with the version deployed on the hosted service, and prints
+what a deployment would change, like a plan: the pipelines added, removed and changed, their start
+blocks, handlers and code, the zsource version, and the schema files, whose changes need a migration.
+Nothing is deployed.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		err := diffProjects(cmd)
+		cobra.CheckErr(err)
+	},
+}
+
+func init() {
+	diffCmd.Flags().String("api-key", "", "Zettablock api key (default: apikey of project.yml)")
+}
+
+// planEntry is a change of a plan, such as "~ pipeline transfers", with the
+// changes it is made of.
+type planEntry struct {
+	// action is "+", "-" or "~".
+	action string
+	what   string
+	nested []planEntry
+}
+
+// projectPlan is what deploying a project would change.
+type projectPlan struct {
+	project  string
+	deployed *api.Definition
+	notes    []string
+	entries  []planEntry
+	// migration is set if a schema file changed.
+	migration bool
+}
+
+func diffProjects(cmd *cobra.Command) error {
+	configs, err := cli.LoadProjects(cmd)
+	if err != nil {
+		return err
+	}
+	for i := range configs {
+		client, err := cli.APIClient(cmd, &configs[i])
+		if err != nil {
+			return projectError(configs, i, err)
+		}
+		deployed, err := client.Definition(cmd.Context(), configs[i].Org, configs[i].Name)
+		if err != nil {
+			return projectError(configs, i, err)
+		}
+		plan, err := planDeployment(&configs[i], deployed)
+		if err != nil {
+			return projectError(configs, i, err)
+		}
+		if i > 0 {
+			fmt.Println()
+		}
+		plan.print()
+	}
+	return nil
+}
+
+// planDeployment compares a project with its deployed definition, nil if
+// it was never deployed.
+func planDeployment(config *internal.ProjectConfig, deployed *api.Definition) (*projectPlan, error) {
+	plan := &projectPlan{project: config.Name, deployed: deployed}
+	if deployed == nil {
+		deployed = &api.Definition{}
+	}
+
+	if deployed.Version != "" {
+		if sameVersion(config.Version, deployed.Version) {
+			plan.notes = append(plan.notes, fmt.Sprintf("version %s is already deployed, deploy will refuse it: bump it, e.g. with deploy --bump patch", config.Version))
+		} else {
+			plan.entries = append(plan.entries, planEntry{"~", fmt.Sprintf("version: %s -> %s", deployed.Version, config.Version), nil})
+		}
+	}
+
+	zsource, err := gomod.ZSource(config.Root)
+	if err != nil {
+		return nil, err
+	}
+	if deployed.ZSourceVersion != "" && !sameVersion(zsource.Version, deployed.ZSourceVersion) {
+		local := zsource.Version
+		// as deploy sends it
+		if v, err := semver.NewVersion(local); err == nil {
+			local = v.String()
+		}
+		plan.entries = append(plan.entries, planEntry{"~", fmt.Sprintf("zsource: %s -> %s", deployed.ZSourceVersion, local), nil})
+	}
+
+	// the files changed since the deployed commit, if the repository has it
+	var files []string
+	if deployed.Commit != "" {
+		files, err = git.Diff(config.Root, deployed.Commit)
+		switch {
+		case errors.Is(err, git.ErrNotRepository), errors.Is(err, git.ErrUnknownCommit):
+			plan.notes = append(plan.notes, fmt.Sprintf("code changes are not shown: %s", err))
+		case err != nil:
+			return nil, err
+		}
+	}
+
+	pipelines, err := planPipelines(config, deployed.Pipelines, files)
+	if err != nil {
+		return nil, err
+	}
+	plan.entries = append(plan.entries, pipelines...)
+
+	schemas, err := planSchemas(config.Root, deployed.Schemas)
+	if err != nil {
+		return nil, err
+	}
+	plan.entries = append(plan.entries, schemas...)
+	plan.migration = len(schemas) > 0
+
+	// the other code, shared by the pipelines
+	var shared []string
+	for _, file := range files {
+		if file == projectYmlFile || strings.HasPrefix(file, schemaPath+"/") {
+			continue
+		}
+		if pipelineOf(config.Pipelines, file) == "" {
+			shared = append(shared, file)
+		}
+	}
+	if len(shared) > 0 {
+		plan.entries = append(plan.entries, planEntry{"~", "code: " + strings.Join(shared, ", "), nil})
+	}
+	return plan, nil
+}
+
+func sameVersion(a, b string) bool {
+	va, errA := semver.NewVersion(a)
+	vb, errB := semver.NewVersion(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return va.Equal(vb)
+}
+
+// pipelineOf returns the pipeline a file of the project belongs to, or "".
+func pipelineOf(pipelines []internal.PipelineConfig, file string) string {
+	name, depth := "", 0
+	for _, p := range pipelines {
+		dir := filepath.ToSlash(p.Dir) + "/"
+		// the deepest folder wins, for nested pipelines
+		if strings.HasPrefix(file, dir) && len(dir) > depth {
+			name, depth = p.Name, len(dir)
+		}
+	}
+	return name
+}
+
+func planPipelines(config *internal.ProjectConfig, deployed map[string]string, files []string) ([]planEntry, error) {
+	var entries []planEntry
+	for _, local := range config.Pipelines {
+		data, ok := deployed[local.Name]
+		if !ok {
+			entries = append(entries, planEntry{"+", "pipeline " + local.Name, describePipeline(local)})
+			continue
+		}
+		remote, err := internal.ParsePipelineConfig([]byte(data))
+		if err != nil {
+			return nil, fmt.Errorf("deployed pipeline.yml of %s: %w", local.Name, err)
+		}
+		nested := comparePipelines(remote, local)
+		var code []string
+		for _, file := range files {
+			if pipelineOf(config.Pipelines, file) == local.Name {
+				// compared above
+				if rel, _ := filepath.Rel(local.Dir, filepath.FromSlash(file)); rel != pipelineYmlFile {
+					code = append(code, filepath.ToSlash(rel))
+				}
+			}
+		}
+		if len(code) > 0 {
+			nested = append(nested, planEntry{"~", "code: " + strings.Join(code, ", "), nil})
+		}
+		if len(nested) > 0 {
+			entries = append(entries, planEntry{"~", "pipeline " + local.Name, nested})
+		}
+	}
+
+	var removed []string
+	for name := range deployed {
+		if _, err := config.Pipeline(name); err != nil {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(removed)
+	for _, name := range removed {
+		entries = append(entries, planEntry{"-", "pipeline " + name, nil})
+	}
+	return entries, nil
+}
+
+// describePipeline lists what an added pipeline is made of.
+func describePipeline(p internal.PipelineConfig) []planEntry {
+	entries := []planEntry{{"+", fmt.Sprintf("startBlock: %d", p.Source.StartBlock), nil}}
+	for _, h := range p.EventHandlers {
+		entries = append(entries, planEntry{"+", eventHandlerName(h), nil})
+	}
+	for _, h := range p.BlockHandlers {
+		entries = append(entries, planEntry{"+", blockHandlerName(h), nil})
+	}
+	if p.ReorgHandler != "" {
+		entries = append(entries, planEntry{"+", "reorg handler " + p.ReorgHandler, nil})
+	}
+	return entries
+}
+
+// comparePipelines lists the changes from the deployed configuration of a
+// pipeline to the local one.
+func comparePipelines(remote, local internal.PipelineConfig) []planEntry {
+	var entries []planEntry
+	field := func(name string, from, to any) {
+		if !reflect.DeepEqual(from, to) {
+			entries = append(entries, planEntry{"~", fmt.Sprintf("%s: %v -> %v", name, from, to), nil})
+		}
+	}
+	field("startBlock", remote.Source.StartBlock, local.Source.StartBlock)
+	field("source.type", remote.Source.Type, local.Source.Type)
+	field("source.rpc", remote.Source.Rpc, local.Source.Rpc)
+	field("source.abiFile", remote.Source.AbiFile, local.Source.AbiFile)
+	entries = append(entries, compareSets("address", remote.Source.Addresses, local.Source.Addresses)...)
+
+	var remoteHandlers, localHandlers []string
+	for _, h := range remote.EventHandlers {
+		remoteHandlers = append(remoteHandlers, eventHandlerName(h))
+	}
+	for _, h := range remote.BlockHandlers {
+		remoteHandlers = append(remoteHandlers, blockHandlerName(h))
+	}
+	for _, h := range local.EventHandlers {
+		localHandlers = append(localHandlers, eventHandlerName(h))
+	}
+	for _, h := range local.BlockHandlers {
+		localHandlers = append(localHandlers, blockHandlerName(h))
+	}
+	entries = append(entries, compareSets("", remoteHandlers, localHandlers)...)
+
+	entries = append(entries, compareEnv(remote, local)...)
+
+	field("reorgHandler", remote.ReorgHandler, local.ReorgHandler)
+	field("parallelism", remote.Parallelism, local.Parallelism)
+	if !reflect.DeepEqual(remote.Retry, local.Retry) {
+		entries = append(entries, planEntry{"~", "retry", nil})
+	}
+	return entries
+}
+
+// compareEnv lists the variables of the env section added, removed and
+// changed.
+func compareEnv(remote, local internal.PipelineConfig) []planEntry {
+	var entries []planEntry
+	for _, name := range remote.EnvNames() {
+		if _, ok := local.Env[name]; !ok {
+			entries = append(entries, planEntry{"-", "env " + name, nil})
+		}
+	}
+	for _, name := range local.EnvNames() {
+		v, ok := remote.Env[name]
+		switch {
+		case !ok:
+			entries = append(entries, planEntry{"+", fmt.Sprintf("env %s: %s", name, envSource(local.Env[name])), nil})
+		case v != local.Env[name]:
+			entries = append(entries, planEntry{"~", fmt.Sprintf("env %s: %s -> %s", name, envSource(v), envSource(local.Env[name])), nil})
+		}
+	}
+	return entries
+}
+
+func envSource(v internal.EnvValue) string {
+	if v.Secret != "" {
+		return "secret " + v.Secret
+	}
+	return fmt.Sprintf("%q", v.Value)
+}
+
+func eventHandlerName(h internal.EventHandlerConfig) string {
+	name := fmt.Sprintf("event handler %s: %s", h.Event, h.Handler)
+	if h.Ordered {
+		name += " (ordered)"
+	}
+	return name
+}
+
+func blockHandlerName(h internal.BlockHandlerConfig) string {
+	name := "block handler " + h.Handler
+	if h.Ordered {
+		name += " (ordered)"
+	}
+	return name
+}
+
+// compareSets lists the values removed from remote and added to local, in
+// their order, prefixed with kind.
+func compareSets(kind string, remote, local []string) []planEntry {
+	if kind != "" {
+		kind += " "
+	}
+	in := func(values []string, v string) bool {
+		for _, value := range values {
+			if value == v {
+				return true
+			}
+		}
+		return false
+	}
+	var entries []planEntry
+	for _, v := range remote {
+		if !in(local, v) {
+			entries = append(entries, planEntry{"-", kind + v, nil})
+		}
+	}
+	for _, v := range local {
+		if !in(remote, v) {
+			entries = append(entries, planEntry{"+", kind + v, nil})
+		}
+	}
+	return entries
+}
+
+// planSchemas compares the .sql files of the schemas folder with the
+// deployed ones.
+func planSchemas(root string, deployed map[string]string) ([]planEntry, error) {
+	dir := filepath.Join(root, schemaPath)
+	paths, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil {
+		return nil, err
+	}
+	local := make(map[string]string, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		local[filepath.Base(path)] = string(data)
+	}
+
+	names := make([]string, 0, len(local)+len(deployed))
+	for name := range local {
+		names = append(names, name)
+	}
+	for name := range deployed {
+		if _, ok := local[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var entries []planEntry
+	for _, name := range names {
+		sql, isLocal := local[name]
+		deployedSQL, isDeployed := deployed[name]
+		switch {
+		case !isDeployed:
+			entries = append(entries, planEntry{"+", "schema " + name, nil})
+		case !isLocal:
+			entries = append(entries, planEntry{"-", "schema " + name, nil})
+		case strings.TrimSpace(sql) != strings.TrimSpace(deployedSQL):
+			entries = append(entries, planEntry{"~", "schema " + name, nil})
+		}
+	}
+	return entries, nil
+}
+
+func (p *projectPlan) print() {
+	if p.deployed == nil {
+		fmt.Printf("Project %s is not deployed yet, deploying it creates:\n", p.project)
+	} else {
+		fmt.Printf("Project %s, deployed version %s (commit %.12s):\n", p.project, p.deployed.Version, p.deployed.Commit)
+	}
+	for _, entry := range p.entries {
+		entry.print("  ")
+	}
+	for _, note := range p.notes {
+		fmt.Printf("  Note: %s.\n", note)
+	}
+
+	var added, changed, removed int
+	for _, entry := range p.entries {
+		if !strings.HasPrefix(entry.what, "pipeline ") {
+			continue
+		}
+		switch entry.action {
+		case "+":
+			added++
+		case "~":
+			changed++
+		case "-":
+			removed++
+		}
+	}
+	if len(p.entries) == 0 {
+		fmt.Println("No changes.")
+		return
+	}
+	fmt.Printf("Pipelines: %d to add, %d to change, %d to remove.\n", added, changed, removed)
+	if p.migration {
+		fmt.Println("The schema files changed: the deployment migrates the database.")
+	}
+}
+
+func (e planEntry) print(indent string) {
+	fmt.Printf("%s%s %s\n", indent, e.action, e.what)
+	for _, nested := range e.nested {
+		nested.print(indent + "    ")
+	}
+}
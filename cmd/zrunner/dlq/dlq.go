@@ -0,0 +1,69 @@
+/*
+Copyright © 2024 Zettablock
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package dlq
+
+import (
+	"github.com/Zettablock/zetta-go/internal/cli"
+	dq "github.com/Zettablock/zetta-go/internal/dlq"
+
+	"github.com/spf13/cobra"
+)
+
+// Cmd represents the dlq command
+var Cmd = &cobra.Command{
+	Use:   "dlq [command]",
+	Short: "Inspect and replay dead-lettered handler invocations",
+	Long: `Handler invocations that failed for good are dead-lettered with their input record, error and
+number of attempts. Local runs write them under .zrunner/dlq, one file per pipeline.
+
+With --remote, the dead letters of the deployed project are fetched from the hosted service instead.`,
+	Args: cobra.ExactArgs(1),
+}
+
+func init() {
+	Cmd.AddCommand(listCmd)
+	Cmd.AddCommand(showCmd)
+	Cmd.AddCommand(replayCmd)
+
+	Cmd.PersistentFlags().Bool("remote", false, "use the dead letters of the deployed project")
+	Cmd.PersistentFlags().String("api-key", "", "Zettablock api key, for --remote (default: apikey of project.yml)")
+}
+
+// entries returns the local dead letters of a pipeline, or of all of them if
+// pipeline is empty, or the remote ones with --remote.
+func entries(cmd *cobra.Command, pipeline string) ([]dq.Entry, error) {
+	remote, err := cmd.Flags().GetBool("remote")
+	if err != nil {
+		return nil, err
+	}
+	if !remote {
+		root, err := cli.ProjectRoot(cmd)
+		if err != nil {
+			return nil, err
+		}
+		return dq.Open(root).List(pipeline)
+	}
+
+	config, err := cli.LoadProject(cmd)
+	if err != nil {
+		return nil, err
+	}
+	client, err := cli.APIClient(cmd, &config)
+	if err != nil {
+		return nil, err
+	}
+	return client.DeadLetters(cmd.Context(), config.Org, config.Name, pipeline)
+}
@@ -0,0 +1,70 @@
+/*
+Copyright © 2024 Zettablock
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package dlq
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// maxErrorWidth truncates errors in the list
+const maxErrorWidth = 60
+
+var (
+	listCmd = &cobra.Command{
+		Use:   "list [pipeline]",
+		Short: "List dead-lettered invocations",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := listEntries(cmd, args)
+			cobra.CheckErr(err)
+		},
+	}
+)
+
+func init() {
+}
+
+func listEntries(cmd *cobra.Command, args []string) error {
+	pipeline := ""
+	if len(args) > 0 {
+		pipeline = args[0]
+	}
+	list, err := entries(cmd, pipeline)
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		fmt.Println("No dead letters.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tPIPELINE\tHANDLER\tBLOCK\tATTEMPTS\tTIME\tERROR")
+	for _, entry := range list {
+		errText := strings.ReplaceAll(entry.Error, "\n", " ")
+		if len(errText) > maxErrorWidth {
+			errText = errText[:maxErrorWidth-3] + "..."
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%s\t%s\n", entry.ID, entry.Pipeline, entry.Handler, entry.Block, entry.Attempts, entry.Time.Local().Format(time.DateTime), errText)
+	}
+	return w.Flush()
+}
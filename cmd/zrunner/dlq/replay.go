@@ -0,0 +1,154 @@
+/*
+Copyright © 2024 Zettablock
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package dlq
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/Zettablock/zetta-go/internal"
+	"github.com/Zettablock/zetta-go/internal/cli"
+	dq "github.com/Zettablock/zetta-go/internal/dlq"
+	"github.com/Zettablock/zetta-go/internal/localdb"
+	"github.com/Zettablock/zetta-go/internal/runner"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	replayCmd = &cobra.Command{
+		Use:   "replay [id]...",
+		Short: "Invoke dead-lettered handlers again, after a fix",
+		Long: `replay builds the pipelines and invokes the handler of every given dead letter again with its
+input record, using the local database started by "zrunner dev db up". Without IDs, every dead
+letter of --pipeline, or of all pipelines, is replayed.
+
+Local dead letters that succeed are removed. Remote ones, with --remote, are only replayed locally.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := replayEntries(cmd, args)
+			cobra.CheckErr(err)
+		},
+	}
+)
+
+func init() {
+	replayCmd.Flags().String("pipeline", "", "replay the dead letters of this pipeline only")
+}
+
+func replayEntries(cmd *cobra.Command, ids []string) error {
+	pipelineName, err := cmd.Flags().GetString("pipeline")
+	if err != nil {
+		return err
+	}
+	remote, err := cmd.Flags().GetBool("remote")
+	if err != nil {
+		return err
+	}
+
+	list, err := entries(cmd, pipelineName)
+	if err != nil {
+		return err
+	}
+	if len(ids) > 0 {
+		var selected []dq.Entry
+		for _, id := range ids {
+			entry, err := dq.Find(list, id)
+			if err != nil {
+				return err
+			}
+			selected = append(selected, entry)
+		}
+		list = selected
+	}
+	if len(list) == 0 {
+		fmt.Println("No dead letters to replay.")
+		return nil
+	}
+
+	config, err := cli.LoadProject(cmd)
+	if err != nil {
+		return err
+	}
+	inst, err := localdb.Lookup(config.Root)
+	if err != nil {
+		return err
+	}
+
+	var pipelines []internal.PipelineConfig
+	seen := make(map[string]bool)
+	for _, entry := range list {
+		if seen[entry.Pipeline] {
+			continue
+		}
+		seen[entry.Pipeline] = true
+		pipeline, err := findPipeline(config.Pipelines, entry.Pipeline)
+		if err != nil {
+			return err
+		}
+		pipelines = append(pipelines, pipeline)
+	}
+	build, err := runner.BuildLocal(config.Root, pipelines)
+	if err != nil {
+		return err
+	}
+	env, err := internal.LocalEnv(config.Root, pipelines)
+	if err != nil {
+		return err
+	}
+
+	exec, err := runner.Start(build.Runner, inst, build.Plugins, env, os.Stderr)
+	if err != nil {
+		return err
+	}
+	defer exec.Close()
+
+	var replayed []string
+	failed := 0
+	for _, entry := range list {
+		err := runner.Replay(cmd.Context(), exec, entry)
+		var herr *runner.HandlerError
+		if errors.As(err, &herr) {
+			fmt.Printf("%s failed: %s\n", entry.ID, herr)
+			failed++
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s replayed.\n", entry.ID)
+		replayed = append(replayed, entry.ID)
+	}
+
+	if !remote {
+		if err = dq.Open(config.Root).Remove(replayed...); err != nil {
+			return err
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d dead letters failed again", failed, len(list))
+	}
+	return nil
+}
+
+func findPipeline(pipelines []internal.PipelineConfig, name string) (internal.PipelineConfig, error) {
+	for _, pipeline := range pipelines {
+		if pipeline.Name == name {
+			return pipeline, nil
+		}
+	}
+	return internal.PipelineConfig{}, fmt.Errorf("pipeline %s not found", name)
+}
@@ -0,0 +1,58 @@
+/*
+Copyright © 2024 Zettablock
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package dlq
+
+import (
+	"encoding/json"
+	"fmt"
+
+	dq "github.com/Zettablock/zetta-go/internal/dlq"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	showCmd = &cobra.Command{
+		Use:   "show [id]",
+		Short: "Show a dead-lettered invocation with its input record",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := showEntry(cmd, args[0])
+			cobra.CheckErr(err)
+		},
+	}
+)
+
+func init() {
+}
+
+func showEntry(cmd *cobra.Command, id string) error {
+	list, err := entries(cmd, "")
+	if err != nil {
+		return err
+	}
+	entry, err := dq.Find(list, id)
+	if err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(b))
+	return nil
+}
@@ -0,0 +1,39 @@
+/*
+Copyright © 2024 Zettablock
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package fixtures
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// Cmd represents the fixtures command
+var Cmd = &cobra.Command{
+	Use:   "fixtures [command]",
+	Short: "Manage fixture files of captured chain data",
+	Long: `Fixture files hold blocks, transactions and logs used to run pipelines locally.
+
+Every line of a fixture file is a JSON record:
+  {"chain": "ethereum", "type": "log", "block": 1167044, "data": {...}}
+where data is the row as encoded by the zsource DAO type of the record (ethereum.Block,
+ethereum.Transaction, ethereum.Log, ...). Files ending with .gz are gzip compressed.`,
+	Args: cobra.ExactArgs(1),
+}
+
+func init() {
+	Cmd.AddCommand(validateCmd)
+	Cmd.AddCommand(sliceCmd)
+	Cmd.AddCommand(mergeCmd)
+}
@@ -0,0 +1,82 @@
+/*
+Copyright © 2024 Zettablock
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package fixtures
+
+import (
+	"fmt"
+
+	fx "github.com/Zettablock/zetta-go/internal/fixtures"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	mergeCmd = &cobra.Command{
+		Use:   "merge [file-or-folder]... -o out.ndjson",
+		Short: "Merge fixture files into one, in block order",
+		Long: `merge combines fixture files into one, ordered by block. Identical records are kept once.
+
+Records with the same key but different data are rejected, unless --overwrite is set, in which
+case the record of the last file wins.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := mergeFixtures(cmd, args)
+			cobra.CheckErr(err)
+		},
+	}
+)
+
+func init() {
+	mergeCmd.Flags().StringP("output", "o", "", "output file, gzip compressed if it ends with .gz")
+	mergeCmd.Flags().Bool("overwrite", false, "let later files replace conflicting records")
+	mergeCmd.MarkFlagRequired("output")
+}
+
+func mergeFixtures(cmd *cobra.Command, args []string) error {
+	output, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return err
+	}
+	overwrite, err := cmd.Flags().GetBool("overwrite")
+	if err != nil {
+		return err
+	}
+
+	files, err := fx.Paths(args)
+	if err != nil {
+		return err
+	}
+
+	var sets [][]fx.Record
+	for _, file := range files {
+		records, err := fx.Read(file)
+		if err != nil {
+			return err
+		}
+		sets = append(sets, records)
+	}
+
+	merged, err := fx.Merge(overwrite, sets...)
+	if err != nil {
+		return err
+	}
+	if err = fx.Write(output, merged); err != nil {
+		return err
+	}
+
+	fmt.Printf("Merged %d files into %d records in %s.\n", len(files), len(merged), output)
+	return nil
+}
@@ -0,0 +1,77 @@
+/*
+Copyright © 2024 Zettablock
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package fixtures
+
+import (
+	"errors"
+	"fmt"
+
+	fx "github.com/Zettablock/zetta-go/internal/fixtures"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	sliceCmd = &cobra.Command{
+		Use:   "slice [file-or-folder]... --from N --to M -o out.ndjson",
+		Short: "Extract a block range from fixture files",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := sliceFixtures(cmd, args)
+			cobra.CheckErr(err)
+		},
+	}
+)
+
+func init() {
+	sliceCmd.Flags().Int64("from", 0, "first block of the range")
+	sliceCmd.Flags().Int64("to", 0, "last block of the range")
+	sliceCmd.Flags().StringP("output", "o", "", "output file, gzip compressed if it ends with .gz")
+	sliceCmd.MarkFlagRequired("from")
+	sliceCmd.MarkFlagRequired("to")
+	sliceCmd.MarkFlagRequired("output")
+}
+
+func sliceFixtures(cmd *cobra.Command, args []string) error {
+	from, err := cmd.Flags().GetInt64("from")
+	if err != nil {
+		return err
+	}
+	to, err := cmd.Flags().GetInt64("to")
+	if err != nil {
+		return err
+	}
+	output, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return err
+	}
+	if from > to {
+		return errors.New("from should not be greater than to")
+	}
+
+	records, err := fx.ReadAll(args)
+	if err != nil {
+		return err
+	}
+
+	sliced := fx.Slice(records, from, to)
+	if err = fx.Write(output, sliced); err != nil {
+		return err
+	}
+
+	fmt.Printf("Wrote %d records of blocks %d to %d to %s.\n", len(sliced), from, to, output)
+	return nil
+}
@@ -0,0 +1,64 @@
+/*
+Copyright © 2024 Zettablock
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package fixtures
+
+import (
+	"fmt"
+
+	fx "github.com/Zettablock/zetta-go/internal/fixtures"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	validateCmd = &cobra.Command{
+		Use:   "validate [file-or-folder]...",
+		Short: "Validate fixture files",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			err := validateFixtures(args)
+			cobra.CheckErr(err)
+		},
+	}
+)
+
+func init() {
+}
+
+func validateFixtures(args []string) error {
+	files, err := fx.Paths(args)
+	if err != nil {
+		return err
+	}
+
+	invalid := 0
+	for _, file := range files {
+		problems, err := fx.Validate(file)
+		if err != nil {
+			return err
+		}
+		for _, problem := range problems {
+			fmt.Println(problem)
+		}
+		invalid += len(problems)
+	}
+
+	if invalid > 0 {
+		return fmt.Errorf("found %d invalid records in %d files", invalid, len(files))
+	}
+	fmt.Printf("%d fixture files are valid.\n", len(files))
+	return nil
+}
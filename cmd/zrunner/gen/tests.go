@@ -0,0 +1,144 @@
+/*
+Copyright © 2024 Zettablock
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package gen
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Zettablock/zetta-go/internal"
+	"github.com/Zettablock/zetta-go/internal/cli"
+	"github.com/Zettablock/zetta-go/internal/fixtures"
+	"github.com/Zettablock/zetta-go/internal/runner"
+
+	"github.com/spf13/cobra"
+)
+
+const testdataDir = "testdata"
+
+var (
+	testsCmd = &cobra.Command{
+		Use:   "tests [pipeline-name]...",
+		Short: "Generate handler unit tests",
+		Long: `tests generates a table-driven test for every handler of the pipelines (default: all).
+
+The tests call the handlers with the records of testdata/<handler>.ndjson and check the rows written
+to the destination tables. Handlers get deps backed by the local database, whose destination writes
+are rolled back after each test. To run them:
+
+  zetta-go zrunner dev db up
+  eval $(zetta-go zrunner dev db env)
+  go test ./...
+
+With --fixtures, testdata files are extracted from the given fixtures for each handler.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := generateTests(cmd, args)
+			cobra.CheckErr(err)
+		},
+	}
+)
+
+func init() {
+	testsCmd.Flags().StringSlice("fixtures", nil, "fixture files or folders to extract testdata from")
+	testsCmd.Flags().Int("limit", 10, "maximum number of records extracted per handler")
+	testsCmd.Flags().Bool("force", false, "overwrite existing test and testdata files")
+}
+
+func generateTests(cmd *cobra.Command, args []string) error {
+	fixturePaths, err := cmd.Flags().GetStringSlice("fixtures")
+	if err != nil {
+		return err
+	}
+	limit, err := cmd.Flags().GetInt("limit")
+	if err != nil {
+		return err
+	}
+	force, err := cmd.Flags().GetBool("force")
+	if err != nil {
+		return err
+	}
+
+	config, err := cli.LoadProject(cmd)
+	if err != nil {
+		return err
+	}
+
+	var records []fixtures.Record
+	if len(fixturePaths) > 0 {
+		if records, err = fixtures.ReadAll(fixturePaths); err != nil {
+			return err
+		}
+	}
+
+	pipelines, err := config.SelectPipelines(args)
+	if err != nil {
+		return err
+	}
+
+	for _, pipeline := range pipelines {
+		files, err := internal.GenerateTests(config.Root, pipeline, force)
+		if err != nil {
+			return err
+		}
+		if len(records) > 0 {
+			testdata, err := writeTestdata(config.Root, pipeline, records, limit, force)
+			if err != nil {
+				return err
+			}
+			files = append(files, testdata...)
+		}
+
+		for _, file := range files {
+			fmt.Println(file)
+		}
+	}
+
+	warning, err := internal.TestDriverWarning(config.Root)
+	if warning != "" {
+		fmt.Fprintf(os.Stderr, "Warning: %s.\n", warning)
+	}
+	return err
+}
+
+// writeTestdata writes, for each handler of the pipeline of the project at
+// root, up to limit of the records it would be invoked with.
+func writeTestdata(root string, pipeline internal.PipelineConfig, records []fixtures.Record, limit int, force bool) ([]string, error) {
+	byHandler := make(map[string][]fixtures.Record)
+	for _, task := range runner.Tasks(pipeline, records) {
+		if len(byHandler[task.Handler]) < limit {
+			byHandler[task.Handler] = append(byHandler[task.Handler], task.Record)
+		}
+	}
+
+	dir := filepath.Join(root, pipeline.Dir, testdataDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	var written []string
+	for handler, handlerRecords := range byHandler {
+		path := filepath.Join(dir, handler+".ndjson")
+		if _, err := os.Stat(path); err == nil && !force {
+			continue
+		}
+		if err := fixtures.Write(path, handlerRecords); err != nil {
+			return nil, err
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
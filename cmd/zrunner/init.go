@@ -17,9 +17,10 @@ package zrunner
 
 import (
 	"fmt"
-	"os"
+	"path/filepath"
 
 	"github.com/Zettablock/zetta-go/internal"
+	"github.com/Zettablock/zetta-go/internal/compat"
 
 	"github.com/spf13/cobra"
 )
@@ -35,8 +36,8 @@ config file and the appropriate structure for a zrunner plugin.
 zetta-cli must be run inside of a github repository.)
 `,
 
-		Run: func(_ *cobra.Command, args []string) {
-			path, err := initializeProject()
+		Run: func(cmd *cobra.Command, args []string) {
+			path, err := initializeProject(cmd)
 			cobra.CheckErr(err)
 			fmt.Println("Your zrunner project is ready at: ", path)
 			fmt.Println()
@@ -49,14 +50,21 @@ func init() {
 
 }
 
-func initializeProject() (string, error) {
-	wd, err := os.Getwd()
+func initializeProject(cmd *cobra.Command) (string, error) {
+	wd, err := cmd.Flags().GetString("project-dir")
 	if err != nil {
 		return "", err
 	}
+	if wd == "" {
+		wd = "."
+	}
+	if wd, err = filepath.Abs(wd); err != nil {
+		return "", err
+	}
 
 	project := &internal.Project{
-		WorkingDir: wd,
+		WorkingDir:     wd,
+		ZSourceVersion: compat.Embedded().Recommended(cmd.Root().Version),
 	}
 
 	if err = project.Create(); err != nil {
@@ -0,0 +1,104 @@
+/*
+Copyright © 2024 Zettablock
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package zrunner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Zettablock/zetta-go/internal"
+	"github.com/Zettablock/zetta-go/internal/api"
+)
+
+// deployedPipeline returns the configuration a pipeline was deployed with.
+func deployedPipeline(ctx context.Context, client *api.Client, config *internal.ProjectConfig, name string) (internal.PipelineConfig, error) {
+	deployed, err := client.Definition(ctx, config.Org, config.Name)
+	if err != nil {
+		return internal.PipelineConfig{}, err
+	}
+	if deployed == nil {
+		return internal.PipelineConfig{}, fmt.Errorf("project %s is not deployed", config.Name)
+	}
+	data, ok := deployed.Pipelines[name]
+	if !ok {
+		return internal.PipelineConfig{}, fmt.Errorf("pipeline %s of %s is not deployed", name, config.Name)
+	}
+	pipeline, err := internal.ParsePipelineConfig([]byte(data))
+	if err != nil {
+		return pipeline, fmt.Errorf("deployed pipeline.yml of %s: %w", name, err)
+	}
+	return pipeline, nil
+}
+
+// followJob polls the progress of a job, printing it as it goes, until the
+// job is done or fails.
+func followJob(ctx context.Context, client *api.Client, j *api.Job) error {
+	if j.ID == "" {
+		return errors.New("the service did not return a job ID to follow")
+	}
+
+	state, progress := "", ""
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		if p := jobProgress(j); j.State != state || p != progress {
+			state, progress = j.State, p
+			fmt.Printf("%s: %s%s\n", j.Pipeline, state, progress)
+		}
+
+		switch j.State {
+		case api.JobDone:
+			return nil
+		case api.JobFailed:
+			if j.Error != "" {
+				return fmt.Errorf("job %s of %s failed at block %d: %s", j.ID, j.Pipeline, j.Block, j.Error)
+			}
+			return fmt.Errorf("job %s of %s failed at block %d", j.ID, j.Pipeline, j.Block)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		next, err := client.Job(ctx, j.ID)
+		if err != nil {
+			if ctx.Err() != nil {
+				continue
+			}
+			return err
+		}
+		if next.Pipeline == "" {
+			next.Pipeline = j.Pipeline
+		}
+		j = next
+	}
+}
+
+// jobProgress describes how far a running job is, in percent of its range.
+func jobProgress(j *api.Job) string {
+	if j.State != api.JobRunning || j.Block < j.From {
+		return ""
+	}
+	if j.To <= j.From {
+		return fmt.Sprintf(", block %d", j.Block)
+	}
+	done := min(j.Block, j.To) - j.From + 1
+	return fmt.Sprintf(", block %d of %d-%d (%d%%)", j.Block, j.From, j.To, done*100/(j.To-j.From+1))
+}
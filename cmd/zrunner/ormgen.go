@@ -17,6 +17,9 @@ package zrunner
 
 import (
 	"fmt"
+	"path/filepath"
+
+	"github.com/Zettablock/zetta-go/internal/cli"
 
 	"github.com/spf13/cobra"
 	"gorm.io/gen"
@@ -36,8 +39,10 @@ var ormgenCmd = &cobra.Command{
 	Long: `ormgen generates DAO files from .sql files. 
 	
 	All schema files should contain "create table" script for your tables and be stored in /schemas.`,
-	Run: func(_ *cobra.Command, args []string) {
-		daoPath, err := generateOrm(args)
+	Run: func(cmd *cobra.Command, _ []string) {
+		root, err := cli.ProjectRoot(cmd)
+		cobra.CheckErr(err)
+		daoPath, err := generateOrm(root)
 		cobra.CheckErr(err)
 		fmt.Printf("Models are generated at\n%s.\n", daoPath)
 	},
@@ -55,16 +60,19 @@ func init() {
 	// ormgenCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 }
 
-func generateOrm(_ []string) (string, error) {
+// generateOrm generates the models of the project at root from its schemas.
+func generateOrm(root string) (string, error) {
+	daoPath := filepath.Join(root, packagePath)
 	g := gen.NewGenerator(gen.Config{
-		ModelPkgPath: fmt.Sprintf("./%s", packagePath),
+		OutPath:      filepath.Join(root, "query"),
+		ModelPkgPath: daoPath,
 		Mode:         gen.WithoutContext | gen.WithDefaultQuery | gen.WithQueryInterface,
 	})
 
 	gormdb, err := gorm.Open(rawsql.New(rawsql.Config{
 		DriverName: "postgres",
 		FilePath: []string{
-			schemaPath, // create table sql file directory
+			filepath.Join(root, schemaPath), // create table sql file directory
 		},
 	}))
 	if err != nil {
@@ -77,5 +85,5 @@ func generateOrm(_ []string) (string, error) {
 
 	g.Execute()
 
-	return packagePath, nil
+	return daoPath, nil
 }
@@ -16,11 +16,11 @@ limitations under the License.
 package pipeline
 
 import (
-	"errors"
+	"fmt"
 	"os"
-	"regexp"
 
 	"github.com/Zettablock/zetta-go/internal"
+	"github.com/Zettablock/zetta-go/internal/cli"
 
 	"github.com/spf13/cobra"
 )
@@ -30,8 +30,8 @@ var (
 		Use:   "create [pipeline-name]",
 		Short: "Create a zrunner pipeline",
 		Args:  cobra.ExactArgs(1),
-		Run: func(_ *cobra.Command, args []string) {
-			err := createPipeline(args)
+		Run: func(cmd *cobra.Command, args []string) {
+			err := createPipeline(cmd, args)
 			cobra.CheckErr(err)
 		},
 	}
@@ -40,26 +40,19 @@ var (
 func init() {
 }
 
-func createPipeline(args []string) error {
-	wd, err := os.Getwd()
+func createPipeline(cmd *cobra.Command, args []string) error {
+	root, err := cli.ProjectRoot(cmd)
 	if err != nil {
 		return err
 	}
 
 	pipelineName := args[0]
-
-	pattern := `^[a-zA-Z0-9_-]+$`
-
-	// Compile the regular expression
-	re := regexp.MustCompile(pattern)
-
-	// Check if the string matches the pattern
-	if !re.MatchString(pipelineName) {
-		return errors.New("pipeline name should only contain alphanumeric characters, underscore and hyphen. No spaces or special characters allowed except hyphen and")
+	if err = validateName(pipelineName); err != nil {
+		return err
 	}
 
 	pipeline := &internal.Pipeline{
-		WorkingDir: wd,
+		WorkingDir: root,
 		Name:       pipelineName,
 	}
 	err = pipeline.Create()
@@ -67,5 +60,9 @@ func createPipeline(args []string) error {
 		return err
 	}
 
-	return nil
+	warning, err := internal.TestDriverWarning(root)
+	if warning != "" {
+		fmt.Fprintf(os.Stderr, "Warning: %s.\n", warning)
+	}
+	return err
 }
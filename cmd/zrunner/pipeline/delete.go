@@ -0,0 +1,96 @@
+/*
+Copyright © 2024 Zettablock
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Zettablock/zetta-go/internal"
+	"github.com/Zettablock/zetta-go/internal/api"
+	"github.com/Zettablock/zetta-go/internal/cli"
+	"github.com/Zettablock/zetta-go/internal/prompt"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	deleteCmd = &cobra.Command{
+		Use:   "delete [pipeline-name]",
+		Short: "Delete a pipeline",
+		Long: `delete removes the folder of a pipeline, and its entry in the pipelines list of project.yml.
+With --remote, the pipeline is undeployed from the hosted service first.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := deletePipeline(cmd, args[0])
+			cobra.CheckErr(err)
+		},
+	}
+)
+
+func init() {
+	deleteCmd.Flags().Bool("remote", false, "also undeploy the pipeline from the hosted service")
+	deleteCmd.Flags().String("api-key", "", "Zettablock api key, for --remote (default: apikey of project.yml)")
+	deleteCmd.Flags().BoolP("yes", "y", false, "do not ask for confirmation")
+}
+
+func deletePipeline(cmd *cobra.Command, name string) error {
+	remote, err := cmd.Flags().GetBool("remote")
+	if err != nil {
+		return err
+	}
+	yes, err := cmd.Flags().GetBool("yes")
+	if err != nil {
+		return err
+	}
+
+	config, err := cli.LoadProject(cmd)
+	if err != nil {
+		return err
+	}
+	pipeline, err := config.Pipeline(name)
+	if err != nil {
+		return err
+	}
+	var client *api.Client
+	if remote {
+		if client, err = cli.APIClient(cmd, &config); err != nil {
+			return err
+		}
+	}
+
+	if !yes {
+		question := fmt.Sprintf("Delete pipeline %s and the folder %s", name, pipeline.Dir)
+		if remote {
+			question += ", and undeploy it"
+		}
+		if !prompt.Confirm(question + "?") {
+			return errors.New("deletion cancelled")
+		}
+	}
+
+	if remote {
+		if err = client.DeletePipeline(cmd.Context(), config.Org, config.Name, name); err != nil {
+			return err
+		}
+		fmt.Printf("Pipeline %s undeployed.\n", name)
+	}
+	if err = internal.DeletePipeline(&config, pipeline); err != nil {
+		return err
+	}
+	fmt.Printf("Pipeline %s deleted.\n", name)
+	return nil
+}
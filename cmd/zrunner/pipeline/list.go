@@ -0,0 +1,105 @@
+/*
+Copyright © 2024 Zettablock
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Zettablock/zetta-go/internal/cli"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	listCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the pipelines of the project",
+		Long: `list shows the pipelines of the project: the folders listed under pipelines: in project.yml,
+which may be globs such as "pipelines/*", or else every folder with a pipeline.yml.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, _ []string) {
+			err := listPipelines(cmd)
+			cobra.CheckErr(err)
+		},
+	}
+)
+
+func init() {
+	listCmd.Flags().StringP("output", "o", "table", "output format, table or json")
+}
+
+// pipelineInfo is a pipeline as list prints it.
+type pipelineInfo struct {
+	Name          string   `json:"name"`
+	Dir           string   `json:"dir"`
+	Source        string   `json:"source"`
+	StartBlock    int64    `json:"start_block"`
+	BlockHandlers int      `json:"block_handlers"`
+	EventHandlers int      `json:"event_handlers"`
+	Addresses     []string `json:"addresses"`
+}
+
+func listPipelines(cmd *cobra.Command) error {
+	output, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return err
+	}
+	if output != "table" && output != "json" {
+		return fmt.Errorf("unknown output format %q, use table or json", output)
+	}
+	config, err := cli.LoadProject(cmd)
+	if err != nil {
+		return err
+	}
+
+	infos := make([]pipelineInfo, 0, len(config.Pipelines))
+	for _, p := range config.Pipelines {
+		addresses := p.Source.Addresses
+		if addresses == nil {
+			addresses = []string{}
+		}
+		infos = append(infos, pipelineInfo{
+			Name:          p.Name,
+			Dir:           p.Dir,
+			Source:        p.Source.Type,
+			StartBlock:    p.Source.StartBlock,
+			BlockHandlers: len(p.BlockHandlers),
+			EventHandlers: len(p.EventHandlers),
+			Addresses:     addresses,
+		})
+	}
+
+	if output == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(infos)
+	}
+
+	if len(infos) == 0 {
+		fmt.Println("No pipeline found.")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tFOLDER\tSOURCE\tSTART BLOCK\tBLOCK HANDLERS\tEVENT HANDLERS\tADDRESSES")
+	for _, p := range infos {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%d\t%s\n", p.Name, p.Dir, p.Source, p.StartBlock, p.BlockHandlers, p.EventHandlers, strings.Join(p.Addresses, ","))
+	}
+	return w.Flush()
+}
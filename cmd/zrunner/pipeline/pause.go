@@ -0,0 +1,76 @@
+/*
+Copyright © 2024 Zettablock
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pipeline
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Zettablock/zetta-go/internal/cli"
+	"github.com/Zettablock/zetta-go/internal/git"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	pauseCmd = &cobra.Command{
+		Use:   "pause [pipeline-name]",
+		Short: "Pause a deployed pipeline",
+		Long: `pause stops a pipeline deployed on the hosted service without undeploying it, e.g. during an
+incident or while migrating its tables. The service records who paused it, and --reason.
+resume restarts it where it stopped.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := pausePipeline(cmd, args[0])
+			cobra.CheckErr(err)
+		},
+	}
+)
+
+func init() {
+	pauseCmd.Flags().String("reason", "", "why the pipeline is paused")
+	pauseCmd.Flags().String("api-key", "", "Zettablock api key (default: apikey of project.yml)")
+	pauseCmd.MarkFlagRequired("reason")
+}
+
+func pausePipeline(cmd *cobra.Command, name string) error {
+	reason, err := cmd.Flags().GetString("reason")
+	if err != nil {
+		return err
+	}
+	config, err := cli.LoadProject(cmd)
+	if err != nil {
+		return err
+	}
+	client, err := cli.APIClient(cmd, &config)
+	if err != nil {
+		return err
+	}
+
+	deployed, err := remotePipeline(cmd.Context(), client, &config, name)
+	if err != nil {
+		return err
+	}
+	if p := deployed.Paused; p != nil {
+		return fmt.Errorf("pipeline %s is already paused, by %s on %s: %s", name, p.By, p.At.Local().Format(time.DateTime), p.Reason)
+	}
+
+	if err = client.PausePipeline(cmd.Context(), config.Org, config.Name, name, git.User(config.Root), reason); err != nil {
+		return err
+	}
+	fmt.Printf("Pipeline %s paused.\n", name)
+	return nil
+}
@@ -16,9 +16,20 @@ limitations under the License.
 package pipeline
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/Zettablock/zetta-go/internal"
+	"github.com/Zettablock/zetta-go/internal/api"
+
 	"github.com/spf13/cobra"
 )
 
+// namePattern is what pipeline names may contain.
+var namePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
 // Cmd represents the zrunner command
 var Cmd = &cobra.Command{
 	Use:   "pipeline [command]",
@@ -28,6 +39,12 @@ var Cmd = &cobra.Command{
 
 func init() {
 	Cmd.AddCommand(createCmd)
+	Cmd.AddCommand(listCmd)
+	Cmd.AddCommand(showCmd)
+	Cmd.AddCommand(renameCmd)
+	Cmd.AddCommand(deleteCmd)
+	Cmd.AddCommand(pauseCmd)
+	Cmd.AddCommand(resumeCmd)
 
 	// Here you will define your flags and configuration settings.
 
@@ -39,3 +56,25 @@ func init() {
 	// is called directly, e.g.:
 	// Cmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 }
+
+func validateName(name string) error {
+	if !namePattern.MatchString(name) {
+		return errors.New("pipeline name should only contain alphanumeric characters, underscore and hyphen. No spaces or special characters allowed")
+	}
+	return nil
+}
+
+// remotePipeline returns the pipeline of the deployed project with the
+// given name.
+func remotePipeline(ctx context.Context, client *api.Client, config *internal.ProjectConfig, name string) (api.RemotePipeline, error) {
+	pipelines, err := client.Pipelines(ctx, config.Org, config.Name)
+	if err != nil {
+		return api.RemotePipeline{}, err
+	}
+	for _, p := range pipelines {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+	return api.RemotePipeline{}, fmt.Errorf("pipeline %s of %s is not deployed", name, config.Name)
+}
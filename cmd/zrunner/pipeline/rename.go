@@ -0,0 +1,63 @@
+/*
+Copyright © 2024 Zettablock
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pipeline
+
+import (
+	"fmt"
+
+	"github.com/Zettablock/zetta-go/internal"
+	"github.com/Zettablock/zetta-go/internal/cli"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	renameCmd = &cobra.Command{
+		Use:   "rename [pipeline-name] [new-name]",
+		Short: "Rename a pipeline",
+		Long: `rename renames a pipeline in its pipeline.yml and moves its folder accordingly, so that they
+still match. An entry of the pipelines list of project.yml naming the folder is updated too.
+
+A deployed pipeline is not renamed: deploy the project to deploy it under its new name, and remove
+the old one with "zrunner undeploy [pipeline-name]".`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := renamePipeline(cmd, args[0], args[1])
+			cobra.CheckErr(err)
+		},
+	}
+)
+
+func renamePipeline(cmd *cobra.Command, name, newName string) error {
+	if err := validateName(newName); err != nil {
+		return err
+	}
+	config, err := cli.LoadProject(cmd)
+	if err != nil {
+		return err
+	}
+	pipeline, err := config.Pipeline(name)
+	if err != nil {
+		return err
+	}
+
+	dir, err := internal.RenamePipeline(&config, pipeline, newName)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Pipeline %s renamed to %s, in %s.\n", name, newName, dir)
+	return nil
+}
@@ -0,0 +1,66 @@
+/*
+Copyright © 2024 Zettablock
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pipeline
+
+import (
+	"fmt"
+
+	"github.com/Zettablock/zetta-go/internal/cli"
+	"github.com/Zettablock/zetta-go/internal/git"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	resumeCmd = &cobra.Command{
+		Use:   "resume [pipeline-name]",
+		Short: "Resume a paused pipeline",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := resumePipeline(cmd, args[0])
+			cobra.CheckErr(err)
+		},
+	}
+)
+
+func init() {
+	resumeCmd.Flags().String("api-key", "", "Zettablock api key (default: apikey of project.yml)")
+}
+
+func resumePipeline(cmd *cobra.Command, name string) error {
+	config, err := cli.LoadProject(cmd)
+	if err != nil {
+		return err
+	}
+	client, err := cli.APIClient(cmd, &config)
+	if err != nil {
+		return err
+	}
+
+	deployed, err := remotePipeline(cmd.Context(), client, &config, name)
+	if err != nil {
+		return err
+	}
+	if deployed.Paused == nil {
+		return fmt.Errorf("pipeline %s is not paused", name)
+	}
+
+	if err = client.ResumePipeline(cmd.Context(), config.Org, config.Name, name, git.User(config.Root)); err != nil {
+		return err
+	}
+	fmt.Printf("Pipeline %s resumed.\n", name)
+	return nil
+}
@@ -0,0 +1,66 @@
+/*
+Copyright © 2024 Zettablock
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pipeline
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Zettablock/zetta-go/internal/cli"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	showCmd = &cobra.Command{
+		Use:   "show [pipeline-name]",
+		Short: "Show the configuration of a pipeline",
+		Long: `show prints the configuration of a pipeline as it is run and deployed, defaults included,
+along with the folder it was found in.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := showPipeline(cmd, args[0])
+			cobra.CheckErr(err)
+		},
+	}
+)
+
+func showPipeline(cmd *cobra.Command, name string) error {
+	config, err := cli.LoadProject(cmd)
+	if err != nil {
+		return err
+	}
+	pipeline, err := config.Pipeline(name)
+	if err != nil {
+		return err
+	}
+
+	pipeline.Parallelism = max(pipeline.Parallelism, 1)
+	policy, err := pipeline.RetryPolicy()
+	if err != nil {
+		return fmt.Errorf("pipeline %s: %w", name, err)
+	}
+	retry := policy.Config()
+	pipeline.Retry = &retry
+
+	encoder := yaml.NewEncoder(os.Stdout)
+	encoder.SetIndent(2)
+	if err = encoder.Encode(&pipeline); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
@@ -0,0 +1,94 @@
+/*
+Copyright © 2024 Zettablock
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package zrunner
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Zettablock/zetta-go/internal/cli"
+	"github.com/Zettablock/zetta-go/internal/prompt"
+
+	"github.com/spf13/cobra"
+)
+
+// reindexCmd represents the reindex command
+var reindexCmd = &cobra.Command{
+	Use:   "reindex [pipeline-name]",
+	Short: "Process every block again with a deployed pipeline",
+	Long: `reindex has a pipeline deployed on the hosted service process every block again, from its
+source.startBlock, and follows its progress until it is done. With --truncate, the rows of the
+pipeline's tables are deleted first, after confirmation.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		err := reindex(cmd, args[0])
+		cobra.CheckErr(err)
+	},
+}
+
+func init() {
+	reindexCmd.Flags().Bool("truncate", false, "delete the rows of the pipeline's tables before reindexing")
+	reindexCmd.Flags().String("api-key", "", "Zettablock api key (default: apikey of project.yml)")
+	reindexCmd.Flags().Bool("detach", false, "return once the reindex is submitted, without following it")
+	reindexCmd.Flags().BoolP("yes", "y", false, "do not ask for confirmation")
+}
+
+func reindex(cmd *cobra.Command, name string) error {
+	truncate, err := cmd.Flags().GetBool("truncate")
+	if err != nil {
+		return err
+	}
+	detach, err := cmd.Flags().GetBool("detach")
+	if err != nil {
+		return err
+	}
+	yes, err := cmd.Flags().GetBool("yes")
+	if err != nil {
+		return err
+	}
+
+	config, err := cli.LoadProject(cmd)
+	if err != nil {
+		return err
+	}
+	client, err := cli.APIClient(cmd, &config)
+	if err != nil {
+		return err
+	}
+	pipeline, err := deployedPipeline(cmd.Context(), client, &config, name)
+	if err != nil {
+		return err
+	}
+	if truncate && !yes {
+		question := fmt.Sprintf("Every row of the tables of %s, in %s, will be deleted, which cannot be undone. Reindex it from block %d?", name, config.Name, pipeline.Source.StartBlock)
+		if !prompt.Confirm(question) {
+			return errors.New("reindex cancelled")
+		}
+	}
+
+	j, err := client.Reindex(cmd.Context(), config.Org, config.Name, name, truncate)
+	if err != nil {
+		return err
+	}
+	if j.Pipeline == "" {
+		j.Pipeline = name
+	}
+	fmt.Printf("Reindex of %s from block %d submitted.\n", name, pipeline.Source.StartBlock)
+	if detach {
+		return nil
+	}
+	return followJob(cmd.Context(), client, j)
+}
@@ -0,0 +1,117 @@
+/*
+Copyright © 2024 Zettablock
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package zrunner
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/Zettablock/zetta-go/internal"
+	"github.com/Zettablock/zetta-go/internal/api"
+	"github.com/Zettablock/zetta-go/internal/git"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+const (
+	projectYmlFile  = "project.yml"
+	pipelineYmlFile = "pipeline.yml"
+)
+
+// newVersion returns the version to deploy a project as: its version, or
+// the next one if bump is set. A version the service already has is an
+// error.
+func newVersion(ctx context.Context, client *api.Client, config *internal.ProjectConfig, bump string) (string, error) {
+	v, err := semver.NewVersion(config.Version)
+	if err != nil {
+		return "", fmt.Errorf("invalid version %q", config.Version)
+	}
+	switch bump {
+	case "":
+	case "patch":
+		*v = v.IncPatch()
+	case "minor":
+		*v = v.IncMinor()
+	case "major":
+		*v = v.IncMajor()
+	default:
+		return "", fmt.Errorf("unknown --bump %q, use patch, minor or major", bump)
+	}
+
+	deployed, err := client.Versions(ctx, config.Org, config.Name)
+	if err != nil {
+		return "", fmt.Errorf("listing the deployed versions: %w", err)
+	}
+	for _, d := range deployed {
+		if dv, err := semver.NewVersion(d.Version); err == nil && dv.Equal(v) {
+			if bump == "" {
+				return "", fmt.Errorf("version %s of %s is already deployed, bump it in project.yml or use --bump", v, config.Name)
+			}
+			return "", fmt.Errorf("version %s of %s is already deployed", v, config.Name)
+		}
+	}
+	return v.String(), nil
+}
+
+// release writes the version of the payload to project.yml and commits it
+// if bump is set, tags the commit if tag is set, and pushes both. With
+// allowDirty, a failed push is only a warning.
+func release(config *internal.ProjectConfig, payload *Payload, bump, tag, allowDirty bool) error {
+	var tags []string
+	if bump {
+		if payload.Ref == "" {
+			return fmt.Errorf("no branch is checked out to commit version %s to", payload.Version)
+		}
+		if err := internal.SetVersion(config, payload.Version); err != nil {
+			return err
+		}
+		commit, err := git.Commit(config.Root, fmt.Sprintf("Release %s %s", config.Name, payload.Version), projectYmlFile)
+		if err != nil {
+			return err
+		}
+		payload.Commit = commit
+		fmt.Printf("Version of %s bumped to %s.\n", config.Name, payload.Version)
+	}
+	if tag {
+		if payload.Commit == "" {
+			return fmt.Errorf("no commit to tag with version %s", payload.Version)
+		}
+		name := releaseTag(config, payload.Version)
+		if err := git.Tag(config.Root, name, payload.Commit); err != nil {
+			return err
+		}
+		tags = append(tags, name)
+		fmt.Printf("Commit %.12s tagged %s.\n", payload.Commit, name)
+	}
+
+	if err := git.Push(config.Root, bump, tags...); err != nil {
+		if !allowDirty {
+			return fmt.Errorf("%w; push the release yourself before deploying again", err)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", err)
+	}
+	return nil
+}
+
+// releaseTag is the git tag of a version of a project: v1.2.3, prefixed by
+// the project name in a workspace.
+func releaseTag(config *internal.ProjectConfig, version string) string {
+	if config.Workspace != "" {
+		return fmt.Sprintf("%s/v%s", config.Name, version)
+	}
+	return "v" + version
+}
@@ -0,0 +1,127 @@
+/*
+Copyright © 2024 Zettablock
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package zrunner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Zettablock/zetta-go/internal/fixtures"
+	"github.com/Zettablock/zetta-go/internal/localdb"
+	"github.com/Zettablock/zetta-go/internal/runner"
+	"github.com/Zettablock/zetta-go/internal/snapshot"
+)
+
+// defaultReorgColumn is the column the default rollback deletes rows by.
+const defaultReorgColumn = "block_number"
+
+// reorg simulates a chain reorganisation to fork and checks that the
+// destination tables end up as after a clean run on the fork. The
+// destination tables must be empty.
+func (l *localRun) reorg(ctx context.Context, records, fork []fixtures.Record, from, to int64) (*runner.Summary, error) {
+	if len(fork) == 0 {
+		return nil, fmt.Errorf("the fork fixture has no records")
+	}
+	canonical := fixtures.Replace(records, fork)
+	forkFrom, forkTo := fork[0].Block, fork[len(fork)-1].Block
+	fmt.Printf("Reorganising blocks %d to %d.\n", forkFrom, forkTo)
+
+	// process the original chain up to the last reorganised block
+	summary, err := l.run(ctx, records, from, min(to, forkTo))
+	if err != nil {
+		return summary, err
+	}
+
+	if err = l.rollback(ctx, forkFrom); err != nil {
+		return summary, err
+	}
+
+	// then the fork, from the first reorganised block
+	if err = loadSource(l.inst, canonical); err != nil {
+		return summary, err
+	}
+	replayed, err := l.run(ctx, canonical, max(from, forkFrom), to)
+	if err != nil {
+		return summary, err
+	}
+	summary.Add(replayed)
+
+	reorganised, err := dumpDestination(l.inst, l.exclude)
+	if err != nil {
+		return summary, err
+	}
+
+	// a clean run on the fork is the expected state
+	if err = resetDestination(l.inst, l.root); err != nil {
+		return summary, err
+	}
+	if _, err = l.run(ctx, canonical, from, to); err != nil {
+		return summary, err
+	}
+	clean, err := dumpDestination(l.inst, l.exclude)
+	if err != nil {
+		return summary, err
+	}
+
+	diffs := snapshot.Diff(clean, reorganised)
+	if len(diffs) > 0 {
+		fmt.Print(snapshot.Report(diffs))
+		return summary, fmt.Errorf("destination tables after the reorg differ from a clean run on the fork")
+	}
+	fmt.Println("Destination tables after the reorg match a clean run on the fork.")
+	return summary, nil
+}
+
+// rollback invokes the reorgHandler of every pipeline that has one. If any
+// pipeline has none, rows at or after block from are deleted from every
+// destination table with a block_number column.
+func (l *localRun) rollback(ctx context.Context, from int64) error {
+	exec, err := l.start()
+	if err != nil {
+		return err
+	}
+	defer exec.Close()
+
+	deleteRows := false
+	for _, pipeline := range l.pipelines {
+		if pipeline.ReorgHandler == "" {
+			deleteRows = true
+			continue
+		}
+		if err = runner.Rollback(ctx, exec, pipeline, from); err != nil {
+			return err
+		}
+		fmt.Printf("Rolled back %s with %s.\n", pipeline.Name, pipeline.ReorgHandler)
+	}
+	if !deleteRows {
+		return nil
+	}
+
+	db, err := l.inst.Open()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	deleted, err := localdb.DeleteFromBlock(db, l.inst.DestinationSchema, defaultReorgColumn, from)
+	if err != nil {
+		return err
+	}
+	for table, count := range deleted {
+		fmt.Printf("Deleted %d rows of %s from block %d.\n", count, table, from)
+	}
+	return nil
+}
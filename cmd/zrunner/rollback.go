@@ -0,0 +1,109 @@
+/*
+Copyright © 2024 Zettablock
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package zrunner
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Zettablock/zetta-go/internal/api"
+	"github.com/Zettablock/zetta-go/internal/cli"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/spf13/cobra"
+)
+
+// rollbackCmd represents the rollback command
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback",
+	Short: "Redeploy a previous version of the project",
+	Long: `rollback redeploys a version of the project deployed before, as "zrunner versions list" shows
+them. The local project is not used, except for its org, name and api key.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		err := rollback(cmd)
+		cobra.CheckErr(err)
+	},
+}
+
+func init() {
+	rollbackCmd.Flags().String("to", "", "version to redeploy")
+	rollbackCmd.Flags().String("api-key", "", "Zettablock api key (default: apikey of project.yml)")
+	rollbackCmd.Flags().Bool("wait", false, "wait until the version runs, and fail if it does not")
+	rollbackCmd.Flags().Duration("timeout", 15*time.Minute, "how long --wait waits, 0 for no limit")
+	rollbackCmd.MarkFlagRequired("to")
+}
+
+func rollback(cmd *cobra.Command) error {
+	to, err := cmd.Flags().GetString("to")
+	if err != nil {
+		return err
+	}
+	wait, err := cmd.Flags().GetBool("wait")
+	if err != nil {
+		return err
+	}
+	timeout, err := cmd.Flags().GetDuration("timeout")
+	if err != nil {
+		return err
+	}
+
+	target, err := semver.NewVersion(to)
+	if err != nil {
+		return fmt.Errorf("invalid version %q", to)
+	}
+	config, err := cli.LoadProject(cmd)
+	if err != nil {
+		return err
+	}
+	client, err := cli.APIClient(cmd, &config)
+	if err != nil {
+		return err
+	}
+	versions, err := client.Versions(cmd.Context(), config.Org, config.Name)
+	if err != nil {
+		return err
+	}
+	var found *api.Version
+	for i, v := range versions {
+		if sv, err := semver.NewVersion(v.Version); err == nil && sv.Equal(target) {
+			found = &versions[i]
+		}
+	}
+	switch {
+	case found == nil:
+		return fmt.Errorf("version %s of %s was never deployed, see zrunner versions list", target, config.Name)
+	case found.Current:
+		return fmt.Errorf("version %s of %s is already running", found.Version, config.Name)
+	}
+
+	d, err := client.Rollback(cmd.Context(), config.Org, config.Name, found.Version, wait)
+	if err != nil {
+		return err
+	}
+	if d.Project == "" {
+		d.Project = config.Name
+	}
+	if wait && d.ID == "" {
+		fmt.Printf("Rollback of %s to %s submitted, no deployment info.\n", config.Name, found.Version)
+	} else {
+		fmt.Printf("Rollback of %s to %s submitted.\n", config.Name, found.Version)
+	}
+	if !wait {
+		return nil
+	}
+	return waitForDeployment(cmd.Context(), client, d, timeout)
+}
@@ -0,0 +1,393 @@
+/*
+Copyright © 2024 Zettablock
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package zrunner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/Zettablock/zetta-go/internal"
+	"github.com/Zettablock/zetta-go/internal/cli"
+	"github.com/Zettablock/zetta-go/internal/dlq"
+	"github.com/Zettablock/zetta-go/internal/fixtures"
+	"github.com/Zettablock/zetta-go/internal/localdb"
+	"github.com/Zettablock/zetta-go/internal/runner"
+	"github.com/Zettablock/zetta-go/internal/snapshot"
+	"github.com/Zettablock/zetta-go/internal/state"
+
+	"github.com/spf13/cobra"
+)
+
+// runCmd represents the run command
+var runCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Run the pipelines locally against fixture data",
+	Long: `run replays fixture files through the pipeline handlers, using the local database started by
+"zrunner dev db up".
+
+The fixture records are loaded into the source tables first. Then, block by block, every block
+handler is invoked with the block and every event handler with the matching logs.
+Pipelines are built as Go plugins under .zrunner/build.
+
+With --snapshot or --check, the destination tables are recreated from schemas/ before the run and
+pipelines process one block at a time, so that the output only depends on the fixtures. --snapshot
+then dumps every destination table as sorted, canonical JSON into the given folder, and --check
+compares the tables with such a dump and fails if they differ. The columns listed under
+snapshot.exclude of project.yml, as column or table.column, are left out of the dumps.
+
+--reorg simulates a chain reorganisation. The blocks N..M covered by the given fork fixture replace
+those of --fixtures: blocks up to M are first processed on the original chain, then the rollback
+hook of every pipeline is invoked from block N and blocks from N are processed again on the fork.
+The rollback hook is the reorgHandler of pipeline.yml, called with N, or by default deleting the rows
+of every destination table whose block_number is N or more. The resulting destination tables must
+match those of a clean run on the fork.
+
+The progress of every pipeline (last processed block, last block and failures of every handler) is
+recorded under .zrunner/state, see "zrunner state show". --resume skips, for every pipeline, the
+blocks processed by earlier runs, so that an interrupted run can be continued. --reset forgets the
+recorded progress of the pipelines before running.
+
+Each pipeline processes up to its parallelism blocks of pipeline.yml at a time, one by default.
+Handlers declared ordered are still invoked in block order. Pipelines run concurrently. Ctrl-C stops
+the run once in-flight handlers return, and the run can be continued with --resume.
+
+Failed invocations are retried as the retry block of pipeline.yml says. An invocation whose retries
+are exhausted is written to .zrunner/dlq and the run goes on. Any other failure stops the run.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		summary, err := runLocal(ctx, cmd)
+		if summary != nil {
+			printSummary(summary)
+		}
+		if errors.Is(err, context.Canceled) {
+			err = errors.New("interrupted, continue with --resume")
+		}
+		cobra.CheckErr(err)
+	},
+}
+
+func init() {
+	runCmd.Flags().StringSlice("fixtures", nil, "fixture files or folders to replay")
+	runCmd.Flags().Int64("from", 0, "first block to process (default: the lowest pipeline start block)")
+	runCmd.Flags().Int64("to", math.MaxInt64, "last block to process (default: the last fixture block)")
+	runCmd.Flags().StringSlice("pipeline", nil, "pipelines to run (default: all)")
+	runCmd.Flags().String("snapshot", "", "dump the destination tables into this folder after the run")
+	runCmd.Flags().String("check", "", "compare the destination tables with the snapshot in this folder after the run")
+	runCmd.MarkFlagRequired("fixtures")
+	runCmd.Flags().String("reorg", "", "fixture file of an alternate fork to reorganise the chain to, see --help")
+	runCmd.MarkFlagsMutuallyExclusive("snapshot", "check", "reorg")
+	runCmd.Flags().Bool("resume", false, "continue every pipeline after the last block it processed")
+	runCmd.Flags().Bool("reset", false, "forget the recorded progress of the pipelines before running")
+	runCmd.MarkFlagsMutuallyExclusive("resume", "reset")
+	runCmd.MarkFlagsMutuallyExclusive("resume", "snapshot")
+	runCmd.MarkFlagsMutuallyExclusive("resume", "check")
+	runCmd.MarkFlagsMutuallyExclusive("resume", "reorg")
+}
+
+func runLocal(ctx context.Context, cmd *cobra.Command) (*runner.Summary, error) {
+	fixturePaths, err := cmd.Flags().GetStringSlice("fixtures")
+	if err != nil {
+		return nil, err
+	}
+	from, err := cmd.Flags().GetInt64("from")
+	if err != nil {
+		return nil, err
+	}
+	to, err := cmd.Flags().GetInt64("to")
+	if err != nil {
+		return nil, err
+	}
+	names, err := cmd.Flags().GetStringSlice("pipeline")
+	if err != nil {
+		return nil, err
+	}
+	snapshotDir, err := cmd.Flags().GetString("snapshot")
+	if err != nil {
+		return nil, err
+	}
+	checkDir, err := cmd.Flags().GetString("check")
+	if err != nil {
+		return nil, err
+	}
+	forkPath, err := cmd.Flags().GetString("reorg")
+	if err != nil {
+		return nil, err
+	}
+	resume, err := cmd.Flags().GetBool("resume")
+	if err != nil {
+		return nil, err
+	}
+	reset, err := cmd.Flags().GetBool("reset")
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := cli.LoadProject(cmd)
+	if err != nil {
+		return nil, err
+	}
+	pipelines, err := config.SelectPipelines(names)
+	if err != nil {
+		return nil, err
+	}
+
+	inst, err := localdb.Lookup(config.Root)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := fixtures.ReadAll(fixturePaths)
+	if err != nil {
+		return nil, err
+	}
+	if err = loadSource(inst, records); err != nil {
+		return nil, err
+	}
+	if snapshotDir != "" || checkDir != "" || forkPath != "" {
+		if err = resetDestination(inst, config.Root); err != nil {
+			return nil, err
+		}
+		pipelines = sequential(pipelines)
+	}
+
+	local, err := buildLocalRun(config.Root, inst, pipelines)
+	if err != nil {
+		return nil, err
+	}
+	local.dlq = dlq.Open(config.Root)
+	local.exclude = config.Snapshot.Exclude
+
+	if forkPath != "" {
+		fork, err := fixtures.ReadAll([]string{forkPath})
+		if err != nil {
+			return nil, err
+		}
+		return local.reorg(ctx, records, fork, from, to)
+	}
+
+	if local.state, err = openState(config.Root, pipelines, reset, resume); err != nil {
+		return nil, err
+	}
+	local.resume = resume
+
+	summary, err := local.run(ctx, records, from, to)
+	if err != nil {
+		return summary, err
+	}
+
+	switch {
+	case snapshotDir != "":
+		err = writeSnapshot(inst, snapshotDir, local.exclude)
+	case checkDir != "":
+		err = checkSnapshot(inst, checkDir, local.exclude)
+	}
+	return summary, err
+}
+
+// printSummary prints the counts of a run, the throughput and the latency
+// of every handler.
+func printSummary(summary *runner.Summary) {
+	fmt.Printf("Processed %d blocks with %d handler invocations in %s (%.1f blocks/s).\n", summary.Blocks, summary.Invocations, summary.Elapsed, summary.Throughput())
+	if summary.DeadLetters > 0 {
+		fmt.Printf("%d invocations failed for good and were dead-lettered, list them with zetta-go zrunner dlq list.\n", summary.DeadLetters)
+	}
+
+	stats := summary.Handlers()
+	if len(stats) == 0 {
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PIPELINE\tHANDLER\tINVOCATIONS\tP50\tP99")
+	for _, s := range stats {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", s.Pipeline, s.Handler, s.Invocations, s.P50, s.P99)
+	}
+	w.Flush()
+}
+
+// sequential returns copies of pipelines that process one block at a time,
+// so that the rows they write, and the serial ids of those, do not depend on
+// the scheduling of handlers.
+func sequential(pipelines []internal.PipelineConfig) []internal.PipelineConfig {
+	copies := make([]internal.PipelineConfig, len(pipelines))
+	for i, pipeline := range pipelines {
+		pipeline.Parallelism = 1
+		copies[i] = pipeline
+	}
+	return copies
+}
+
+// loadSource replaces the source tables of the local database with records.
+func loadSource(inst *localdb.Instance, records []fixtures.Record) error {
+	db, err := inst.Open()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if err = localdb.ResetSchema(db, inst.SourceSchema); err != nil {
+		return err
+	}
+	_, err = fixtures.Load(db, inst.SourceSchema, records)
+	return err
+}
+
+// openState loads the recorded progress, forgetting that of the pipelines if
+// reset is set.
+func openState(root string, pipelines []internal.PipelineConfig, reset, resume bool) (*state.Store, error) {
+	store, err := state.Open(root)
+	if err != nil {
+		return nil, err
+	}
+	for _, pipeline := range pipelines {
+		if reset {
+			if err = store.Reset(pipeline.Name); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if last, ok := store.Resume(pipeline.Name); ok && resume {
+			fmt.Printf("Resuming %s after block %d.\n", pipeline.Name, last)
+		}
+	}
+	return store, nil
+}
+
+// resetDestination recreates the destination tables, empty.
+func resetDestination(inst *localdb.Instance, root string) error {
+	db, err := inst.Open()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if err = localdb.ResetSchema(db, inst.DestinationSchema); err != nil {
+		return err
+	}
+	_, err = localdb.ApplySchemas(db, inst.DestinationSchema, filepath.Join(root, schemaPath))
+	return err
+}
+
+// dumpDestination dumps the destination tables, without the columns listed
+// in exclude.
+func dumpDestination(inst *localdb.Instance, exclude []string) (snapshot.Snapshot, error) {
+	db, err := inst.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	return snapshot.Dump(db, inst.DestinationSchema, exclude)
+}
+
+func writeSnapshot(inst *localdb.Instance, dir string, exclude []string) error {
+	snap, err := dumpDestination(inst, exclude)
+	if err != nil {
+		return err
+	}
+	if err = snapshot.Write(dir, snap); err != nil {
+		return err
+	}
+	fmt.Printf("Snapshot of %d tables written to %s.\n", len(snap), dir)
+	return nil
+}
+
+func checkSnapshot(inst *localdb.Instance, dir string, exclude []string) error {
+	expected, err := snapshot.Read(dir)
+	if err != nil {
+		return err
+	}
+	actual, err := dumpDestination(inst, exclude)
+	if err != nil {
+		return err
+	}
+
+	diffs := snapshot.Diff(expected, actual)
+	if len(diffs) > 0 {
+		fmt.Print(snapshot.Report(diffs))
+		return fmt.Errorf("destination tables differ from the snapshot in %s", dir)
+	}
+	fmt.Printf("Destination tables match the snapshot in %s.\n", dir)
+	return nil
+}
+
+// localRun runs built pipelines against the local database.
+type localRun struct {
+	root       string
+	inst       *localdb.Instance
+	pipelines  []internal.PipelineConfig
+	runnerPath string
+	plugins    map[string]string
+	env        []string
+	// state, if set, records the progress of the run
+	state  *state.Store
+	resume bool
+	dlq    *dlq.Store
+	// exclude lists the columns left out of destination dumps
+	exclude []string
+}
+
+// buildLocalRun builds every pipeline and the runner program.
+func buildLocalRun(root string, inst *localdb.Instance, pipelines []internal.PipelineConfig) (*localRun, error) {
+	build, err := runner.BuildLocal(root, pipelines)
+	if err != nil {
+		return nil, err
+	}
+	local := &localRun{
+		root:       root,
+		inst:       inst,
+		pipelines:  pipelines,
+		runnerPath: build.Runner,
+		plugins:    build.Plugins,
+	}
+	if local.env, err = internal.LocalEnv(root, pipelines); err != nil {
+		return nil, err
+	}
+	return local, nil
+}
+
+// start launches a runner program. Each phase of a run gets its own, so that
+// no connection outlives the source tables it has seen.
+func (l *localRun) start() (*runner.Executor, error) {
+	return runner.Start(l.runnerPath, l.inst, l.plugins, l.env, os.Stderr)
+}
+
+// run processes the blocks of records within [from, to].
+func (l *localRun) run(ctx context.Context, records []fixtures.Record, from, to int64) (*runner.Summary, error) {
+	exec, err := l.start()
+	if err != nil {
+		return nil, err
+	}
+	defer exec.Close()
+
+	return runner.Run(ctx, exec, runner.Options{
+		Pipelines:   l.pipelines,
+		Records:     records,
+		From:        from,
+		To:          to,
+		State:       l.state,
+		Resume:      l.resume,
+		DeadLetters: l.dlq,
+	})
+}
@@ -0,0 +1,71 @@
+/*
+Copyright © 2024 Zettablock
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package secrets
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	listCmd = &cobra.Command{
+		Use:   "list [pipeline-name]",
+		Short: "List the secrets of a pipeline",
+		Long: `list shows the secrets set for a pipeline, without their values, and the secrets the env
+section of pipeline.yml references that are not set, which would fail a deployment.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := listSecrets(cmd, args[0])
+			cobra.CheckErr(err)
+		},
+	}
+)
+
+func init() {
+}
+
+func listSecrets(cmd *cobra.Command, pipelineName string) error {
+	config, pipeline, client, err := loadPipeline(cmd, pipelineName)
+	if err != nil {
+		return err
+	}
+	list, err := client.Secrets(cmd.Context(), config.Org, config.Name, pipelineName)
+	if err != nil {
+		return err
+	}
+
+	set := make(map[string]bool, len(list))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SECRET\tUPDATED\tBY\t")
+	for _, s := range list {
+		set[s.Name] = true
+		note := ""
+		if !referenced(pipeline, s.Name) {
+			note = "(not referenced)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", s.Name, s.UpdatedAt.Local().Format(time.DateTime), s.UpdatedBy, note)
+	}
+	for _, name := range pipeline.Secrets() {
+		if !set[name] {
+			fmt.Fprintf(w, "%s\t-\t-\t(not set)\n", name)
+		}
+	}
+	return w.Flush()
+}
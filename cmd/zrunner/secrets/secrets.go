@@ -0,0 +1,73 @@
+/*
+Copyright © 2024 Zettablock
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package secrets
+
+import (
+	"github.com/Zettablock/zetta-go/internal"
+	"github.com/Zettablock/zetta-go/internal/api"
+	"github.com/Zettablock/zetta-go/internal/cli"
+
+	"github.com/spf13/cobra"
+)
+
+// Cmd represents the secrets command
+var Cmd = &cobra.Command{
+	Use:   "secrets [command]",
+	Short: "Manage the secrets of the deployed pipelines",
+	Long: `Secrets are values, such as API keys, that pipelines get as environment variables without
+keeping them in the repository. The env section of pipeline.yml references them by name:
+
+  env:
+    REGISTRY_API_KEY:
+      secret: registry_api_key
+
+Their values are encrypted with the public key of the hosted service before they are sent, and
+are never returned. For local runs, the .env file of the project, or of the pipeline folder,
+provides them instead.`,
+	Args: cobra.ExactArgs(1),
+}
+
+func init() {
+	Cmd.AddCommand(setCmd)
+	Cmd.AddCommand(listCmd)
+	Cmd.AddCommand(unsetCmd)
+
+	Cmd.PersistentFlags().String("api-key", "", "Zettablock api key (default: apikey of project.yml)")
+}
+
+// loadPipeline reads the configuration of the project the command applies
+// to, and of one of its pipelines, and returns a client of the service.
+func loadPipeline(cmd *cobra.Command, name string) (internal.ProjectConfig, internal.PipelineConfig, *api.Client, error) {
+	config, err := cli.LoadProject(cmd)
+	if err != nil {
+		return config, internal.PipelineConfig{}, nil, err
+	}
+	pipeline, err := config.Pipeline(name)
+	if err != nil {
+		return config, pipeline, nil, err
+	}
+	client, err := cli.APIClient(cmd, &config)
+	return config, pipeline, client, err
+}
+
+func referenced(pipeline internal.PipelineConfig, secret string) bool {
+	for _, s := range pipeline.Secrets() {
+		if s == secret {
+			return true
+		}
+	}
+	return false
+}
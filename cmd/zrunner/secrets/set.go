@@ -0,0 +1,116 @@
+/*
+Copyright © 2024 Zettablock
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Zettablock/zetta-go/internal"
+	"github.com/Zettablock/zetta-go/internal/git"
+	"github.com/Zettablock/zetta-go/internal/secrets"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var (
+	setCmd = &cobra.Command{
+		Use:   "set [pipeline-name] [secret-name]",
+		Short: "Set a secret of a pipeline",
+		Long: `set encrypts a value and sends it to the hosted service as a secret of the pipeline, replacing
+its previous value. The value is read from standard input, so that it stays out of the shell
+history:
+
+  zetta-go zrunner secrets set transfers registry_api_key < key.txt
+
+The pipelines that are running get the new value when they are deployed again.`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := setSecret(cmd, args[0], args[1])
+			cobra.CheckErr(err)
+		},
+	}
+)
+
+func init() {
+}
+
+func setSecret(cmd *cobra.Command, pipelineName, name string) error {
+	if !internal.ValidSecretName(name) {
+		return fmt.Errorf("secret name %s should only contain alphanumeric characters and underscore, and not start with a digit", name)
+	}
+	config, pipeline, client, err := loadPipeline(cmd, pipelineName)
+	if err != nil {
+		return err
+	}
+	if !referenced(pipeline, name) {
+		fmt.Fprintf(os.Stderr, "Warning: the env section of %s does not reference %s.\n", pipelineName, name)
+	}
+
+	value, err := readValue(name)
+	if err != nil {
+		return err
+	}
+	pem, err := client.SecretsKey(cmd.Context())
+	if err != nil {
+		return err
+	}
+	key, err := secrets.ParsePublicKey(pem)
+	if err != nil {
+		return fmt.Errorf("public key of the service: %w", err)
+	}
+	sealed, err := secrets.Seal(key, []byte(value))
+	if err != nil {
+		return err
+	}
+
+	if err = client.SetSecret(cmd.Context(), config.Org, config.Name, pipelineName, name, git.User(config.Root), sealed); err != nil {
+		return err
+	}
+	fmt.Printf("Secret %s of %s set.\n", name, pipelineName)
+	return nil
+}
+
+// readValue reads a secret value from stdin: one line, not echoed, if it is
+// a terminal, everything otherwise, without the final newline.
+func readValue(name string) (string, error) {
+	var value string
+	if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
+		fmt.Printf("Value of %s: ", name)
+		data, err := term.ReadPassword(fd)
+		fmt.Println()
+		if err != nil {
+			return "", err
+		}
+		value = string(data)
+	} else {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", err
+		}
+		value = string(data)
+	}
+
+	value = strings.TrimSuffix(strings.TrimSuffix(value, "\n"), "\r")
+	if value == "" {
+		return "", errors.New("the secret value is empty")
+	}
+	return value, nil
+}
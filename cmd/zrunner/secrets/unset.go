@@ -0,0 +1,77 @@
+/*
+Copyright © 2024 Zettablock
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/Zettablock/zetta-go/internal/prompt"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	unsetCmd = &cobra.Command{
+		Use:   "unset [pipeline-name] [secret-name]",
+		Short: "Delete a secret of a pipeline",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := unsetSecret(cmd, args[0], args[1])
+			cobra.CheckErr(err)
+		},
+	}
+)
+
+func init() {
+	unsetCmd.Flags().BoolP("yes", "y", false, "do not ask for confirmation")
+}
+
+func unsetSecret(cmd *cobra.Command, pipelineName, name string) error {
+	yes, err := cmd.Flags().GetBool("yes")
+	if err != nil {
+		return err
+	}
+	config, pipeline, client, err := loadPipeline(cmd, pipelineName)
+	if err != nil {
+		return err
+	}
+
+	list, err := client.Secrets(cmd.Context(), config.Org, config.Name, pipelineName)
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, s := range list {
+		found = found || s.Name == name
+	}
+	if !found {
+		return fmt.Errorf("secret %s of %s is not set", name, pipelineName)
+	}
+
+	if referenced(pipeline, name) {
+		fmt.Fprintf(os.Stderr, "Warning: the env section of %s references %s, its next deployment will fail without it.\n", pipelineName, name)
+	}
+	if !yes && !prompt.Confirm(fmt.Sprintf("Delete secret %s of %s?", name, pipelineName)) {
+		return errors.New("unset cancelled")
+	}
+	if err = client.UnsetSecret(cmd.Context(), config.Org, config.Name, pipelineName, name); err != nil {
+		return err
+	}
+	fmt.Printf("Secret %s of %s deleted.\n", name, pipelineName)
+	return nil
+}
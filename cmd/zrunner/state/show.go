@@ -0,0 +1,93 @@
+/*
+Copyright © 2024 Zettablock
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package state
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Zettablock/zetta-go/internal/cli"
+	st "github.com/Zettablock/zetta-go/internal/state"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	showCmd = &cobra.Command{
+		Use:   "show [pipeline]...",
+		Short: "Show the progress of the pipelines",
+		Run: func(cmd *cobra.Command, args []string) {
+			err := showState(cmd, args)
+			cobra.CheckErr(err)
+		},
+	}
+)
+
+func init() {
+}
+
+func showState(cmd *cobra.Command, names []string) error {
+	root, err := cli.ProjectRoot(cmd)
+	if err != nil {
+		return err
+	}
+	store, err := st.Open(root)
+	if err != nil {
+		return err
+	}
+
+	shown := 0
+	for _, p := range store.Pipelines() {
+		if len(names) > 0 && !slices.Contains(names, p.Name) {
+			continue
+		}
+		if shown > 0 {
+			fmt.Println()
+		}
+		shown++
+
+		if p.Blocks == 0 {
+			fmt.Printf("%s: no block processed\n", p.Name)
+		} else {
+			fmt.Printf("%s: last block %d, %d blocks processed, updated %s\n", p.Name, p.LastBlock, p.Blocks, p.UpdatedAt.Local().Format(time.DateTime))
+		}
+
+		handlers := make([]string, 0, len(p.Handlers))
+		for name := range p.Handlers {
+			handlers = append(handlers, name)
+		}
+		sort.Strings(handlers)
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "  HANDLER\tCURSOR\tINVOCATIONS\tFAILURES\tLAST ERROR")
+		for _, name := range handlers {
+			h := p.Handlers[name]
+			fmt.Fprintf(w, "  %s\t%d\t%d\t%d\t%s\n", name, h.Cursor, h.Invocations, h.Failures, h.LastError)
+		}
+		if err = w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if shown == 0 {
+		fmt.Println("No progress recorded, run the pipelines with `zrunner run` first.")
+	}
+	return nil
+}
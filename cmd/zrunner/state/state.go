@@ -0,0 +1,34 @@
+/*
+Copyright © 2024 Zettablock
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package state
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// Cmd represents the state command
+var Cmd = &cobra.Command{
+	Use:   "state [command]",
+	Short: "Inspect the progress recorded by local runs",
+	Long: `zrunner run records the progress of every pipeline under .zrunner/state: the last block it
+processed, and for every handler the last block it was invoked for, the number of invocations and
+failures, and the last error. "zrunner run --resume" continues from there.`,
+	Args: cobra.ExactArgs(1),
+}
+
+func init() {
+	Cmd.AddCommand(showCmd)
+}
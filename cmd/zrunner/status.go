@@ -0,0 +1,95 @@
+/*
+Copyright © 2024 Zettablock
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package zrunner
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Zettablock/zetta-go/internal/api"
+	"github.com/Zettablock/zetta-go/internal/cli"
+
+	"github.com/spf13/cobra"
+)
+
+// statusCmd represents the status command
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the state of the deployed pipelines",
+	Long: `status lists the pipelines deployed on the hosted service, with their version, their state and
+the last block they processed. For a paused pipeline, it shows who paused it, when and why.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		err := showStatus(cmd)
+		cobra.CheckErr(err)
+	},
+}
+
+func init() {
+	statusCmd.Flags().String("api-key", "", "Zettablock api key (default: apikey of project.yml)")
+}
+
+func showStatus(cmd *cobra.Command) error {
+	configs, err := cli.LoadProjects(cmd)
+	if err != nil {
+		return err
+	}
+	for i, config := range configs {
+		client, err := cli.APIClient(cmd, &config)
+		if err != nil {
+			return projectError(configs, i, err)
+		}
+		pipelines, err := client.Pipelines(cmd.Context(), config.Org, config.Name)
+		if err != nil {
+			return projectError(configs, i, err)
+		}
+
+		if len(configs) > 1 {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("%s:\n", config.Name)
+		}
+		if len(pipelines) == 0 {
+			fmt.Printf("No pipeline of %s deployed.\n", config.Name)
+			continue
+		}
+		if err = printStatus(pipelines); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func printStatus(pipelines []api.RemotePipeline) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PIPELINE\tVERSION\tSTATE\tBLOCK\t")
+	for _, p := range pipelines {
+		state, note := describeStage(p.Stage), ""
+		if p.Paused != nil {
+			state = "paused"
+			note = fmt.Sprintf("by %s on %s: %s", p.Paused.By, p.Paused.At.Local().Format(time.DateTime), p.Paused.Reason)
+		}
+		block := "-"
+		if p.Block > 0 {
+			block = fmt.Sprint(p.Block)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", p.Name, p.Version, state, block, note)
+	}
+	return w.Flush()
+}
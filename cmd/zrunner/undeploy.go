@@ -0,0 +1,85 @@
+/*
+Copyright © 2024 Zettablock
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package zrunner
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Zettablock/zetta-go/internal/cli"
+	"github.com/Zettablock/zetta-go/internal/prompt"
+
+	"github.com/spf13/cobra"
+)
+
+// undeployCmd represents the undeploy command
+var undeployCmd = &cobra.Command{
+	Use:   "undeploy [pipeline-name]...",
+	Short: "Stop and remove pipelines from the hosted service",
+	Long: `undeploy stops the given pipelines on the hosted service and removes them from the deployed
+project. The other pipelines keep running, and the local project is left as it is.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		err := undeploy(cmd, args)
+		cobra.CheckErr(err)
+	},
+}
+
+func init() {
+	undeployCmd.Flags().String("api-key", "", "Zettablock api key (default: apikey of project.yml)")
+	undeployCmd.Flags().BoolP("yes", "y", false, "do not ask for confirmation")
+}
+
+func undeploy(cmd *cobra.Command, names []string) error {
+	yes, err := cmd.Flags().GetBool("yes")
+	if err != nil {
+		return err
+	}
+
+	config, err := cli.LoadProject(cmd)
+	if err != nil {
+		return err
+	}
+	client, err := cli.APIClient(cmd, &config)
+	if err != nil {
+		return err
+	}
+	remote, err := client.Pipelines(cmd.Context(), config.Org, config.Name)
+	if err != nil {
+		return err
+	}
+	deployed := make(map[string]bool, len(remote))
+	for _, p := range remote {
+		deployed[p.Name] = true
+	}
+	for _, name := range names {
+		if !deployed[name] {
+			return fmt.Errorf("pipeline %s of %s is not deployed", name, config.Name)
+		}
+	}
+
+	if !yes && !prompt.Confirm(fmt.Sprintf("Stop and remove %s from the deployment of %s?", strings.Join(names, ", "), config.Name)) {
+		return errors.New("undeploy cancelled")
+	}
+	for _, name := range names {
+		if err = client.DeletePipeline(cmd.Context(), config.Org, config.Name, name); err != nil {
+			return err
+		}
+		fmt.Printf("Pipeline %s undeployed.\n", name)
+	}
+	return nil
+}
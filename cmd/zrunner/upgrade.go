@@ -0,0 +1,148 @@
+/*
+Copyright © 2024 Zettablock
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package zrunner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/Zettablock/zetta-go/internal/cli"
+	"github.com/Zettablock/zetta-go/internal/gomod"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/spf13/cobra"
+)
+
+const goSumFile = "go.sum"
+
+// upgradeZSourceCmd represents the upgrade-zsource command
+var upgradeZSourceCmd = &cobra.Command{
+	Use:   "upgrade-zsource",
+	Short: "Require the zsource version supported by this zetta-go version",
+	Long: `upgrade-zsource makes go.mod require the zsource version recommended for this version of
+zetta-go, or the one given with --to, using "go get". The pipelines are then built; if one no
+longer compiles, go.mod and go.sum are restored.
+
+Versions outside of the range this zetta-go version supports are refused unless --force is set.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := upgradeZSource(cmd)
+		cobra.CheckErr(err)
+	},
+}
+
+func init() {
+	upgradeZSourceCmd.Flags().String("to", "", "zsource version to require (default: the recommended version)")
+	upgradeZSourceCmd.Flags().Bool("force", false, "require the version even if this zetta-go version does not support it")
+	upgradeZSourceCmd.Flags().String("api-key", "", "Zettablock api key, to use the compatibility matrix of the service")
+}
+
+func upgradeZSource(cmd *cobra.Command) error {
+	target, err := cmd.Flags().GetString("to")
+	if err != nil {
+		return err
+	}
+	force, err := cmd.Flags().GetBool("force")
+	if err != nil {
+		return err
+	}
+
+	config, err := cli.LoadProject(cmd)
+	if err != nil {
+		return err
+	}
+	zsource, err := gomod.ZSource(config.Root)
+	if err != nil {
+		return err
+	}
+	if zsource.Replace != nil {
+		return fmt.Errorf("zsource is replaced with %s, remove the replacement to upgrade it", zsource.Replace)
+	}
+	current := strings.TrimPrefix(zsource.Version, "v")
+
+	// without an api key, the embedded compatibility matrix is used
+	apiKey, err := cli.APIKey(cmd, &config)
+	if err != nil && !errors.Is(err, cli.ErrNoAPIKey) {
+		return err
+	}
+	matrix := compatibilityMatrix(cmd.Context(), apiKey)
+	if target == "" {
+		target = matrix.Recommended(cmd.Root().Version)
+		if target == "" {
+			return fmt.Errorf("no zsource version is known for zetta-go %s, use --to", cmd.Root().Version)
+		}
+	}
+	v, err := semver.NewVersion(target)
+	if err != nil {
+		return fmt.Errorf("invalid zsource version %q", target)
+	}
+	if v.String() == current {
+		fmt.Printf("go.mod already requires zsource v%s.\n", current)
+		return nil
+	}
+	if !force && cmd.Root().Version != "" {
+		if err = matrix.Check(cmd.Root().Version, v.String()); err != nil {
+			return fmt.Errorf("%w, use --force to require it anyway", err)
+		}
+	}
+
+	// keep go.mod and go.sum to restore them if the upgrade breaks the build
+	goModPath := filepath.Join(config.Root, goModFile)
+	goSumPath := filepath.Join(config.Root, goSumFile)
+	goMod, err := os.ReadFile(goModPath)
+	if err != nil {
+		return err
+	}
+	goSum, err := os.ReadFile(goSumPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	restore := func() error {
+		if err := os.WriteFile(goModPath, goMod, 0644); err != nil {
+			return err
+		}
+		if goSum == nil {
+			return os.Remove(goSumPath)
+		}
+		return os.WriteFile(goSumPath, goSum, 0644)
+	}
+
+	get := exec.Command("go", "get", fmt.Sprintf("%s@v%s", gomod.ZSourceModule, v))
+	get.Dir = config.Root
+	if output, err := get.CombinedOutput(); err != nil {
+		if restoreErr := restore(); restoreErr != nil {
+			return restoreErr
+		}
+		return fmt.Errorf("go get failed:\n%s", strings.TrimSpace(string(output)))
+	}
+
+	if len(config.Pipelines) == 0 {
+		fmt.Printf("go.mod now requires zsource v%s instead of v%s.\n", v, current)
+		return nil
+	}
+	if err = checkPipelines(config.Root, config.Pipelines); err != nil {
+		if restoreErr := restore(); restoreErr != nil {
+			return errors.Join(err, restoreErr)
+		}
+		return fmt.Errorf("%w with zsource v%s, go.mod and go.sum restored", err, v)
+	}
+
+	fmt.Printf("go.mod now requires zsource v%s instead of v%s.\n", v, current)
+	return nil
+}
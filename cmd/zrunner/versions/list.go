@@ -0,0 +1,80 @@
+/*
+Copyright © 2024 Zettablock
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package versions
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Zettablock/zetta-go/internal/cli"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	listCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the deployed versions of the project",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, _ []string) {
+			err := listVersions(cmd)
+			cobra.CheckErr(err)
+		},
+	}
+)
+
+func init() {
+}
+
+func listVersions(cmd *cobra.Command) error {
+	config, err := cli.LoadProject(cmd)
+	if err != nil {
+		return err
+	}
+	client, err := cli.APIClient(cmd, &config)
+	if err != nil {
+		return err
+	}
+
+	versions, err := client.Versions(cmd.Context(), config.Org, config.Name)
+	if err != nil {
+		return err
+	}
+	if len(versions) == 0 {
+		fmt.Printf("No version of %s deployed.\n", config.Name)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tCOMMIT\tZSOURCE\tDEPLOYED\t")
+	for _, v := range versions {
+		current := ""
+		if v.Current {
+			current = "(current)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", v.Version, shortCommit(v.Commit), v.ZSourceVersion, v.DeployedAt.Local().Format(time.DateTime), current)
+	}
+	return w.Flush()
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
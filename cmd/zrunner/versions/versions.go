@@ -0,0 +1,35 @@
+/*
+Copyright © 2024 Zettablock
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package versions
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// Cmd represents the versions command
+var Cmd = &cobra.Command{
+	Use:   "versions [command]",
+	Short: "Inspect the deployed versions of the project",
+	Long: `Every deployment of the project is recorded by the hosted service under the version of
+project.yml. Use "zrunner rollback --to <version>" to redeploy one of them.`,
+	Args: cobra.ExactArgs(1),
+}
+
+func init() {
+	Cmd.AddCommand(listCmd)
+
+	Cmd.PersistentFlags().String("api-key", "", "Zettablock api key (default: apikey of project.yml)")
+}
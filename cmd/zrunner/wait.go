@@ -0,0 +1,110 @@
+/*
+Copyright © 2024 Zettablock
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package zrunner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Zettablock/zetta-go/internal/api"
+)
+
+// pollInterval is how often the progress of a deployment is fetched.
+const pollInterval = 3 * time.Second
+
+var stageDescriptions = map[string]string{
+	api.StageQueued:    "queued",
+	api.StageCloning:   "cloning the repository",
+	api.StageBuilding:  "building the plugins",
+	api.StageMigrating: "migrating the schema",
+	api.StageRunning:   "running",
+	api.StageFailed:    "failed",
+}
+
+func describeStage(stage string) string {
+	if d, ok := stageDescriptions[stage]; ok {
+		return d
+	}
+	return stage
+}
+
+// waitForDeployment polls the progress of a deployment, printing every
+// change, until it runs or fails. It gives up after timeout, if not zero.
+func waitForDeployment(ctx context.Context, client *api.Client, d *api.Deployment, timeout time.Duration) error {
+	if d.ID == "" {
+		return errors.New("the service did not return a deployment ID to wait for")
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	stage := ""
+	pipelineStages := make(map[string]string)
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		if d.Stage != stage {
+			stage = d.Stage
+			fmt.Printf("%s: %s\n", d.Project, describeStage(stage))
+		}
+		for _, p := range d.Pipelines {
+			if pipelineStages[p.Name] == p.Stage {
+				continue
+			}
+			pipelineStages[p.Name] = p.Stage
+			if p.Error != "" {
+				fmt.Printf("  %s: %s: %s\n", p.Name, describeStage(p.Stage), p.Error)
+			} else {
+				fmt.Printf("  %s: %s\n", p.Name, describeStage(p.Stage))
+			}
+		}
+
+		switch d.Stage {
+		case api.StageRunning:
+			return nil
+		case api.StageFailed:
+			if d.Error != "" {
+				return fmt.Errorf("deployment %s of %s failed: %s", d.ID, d.Project, d.Error)
+			}
+			return fmt.Errorf("deployment %s of %s failed", d.ID, d.Project)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("deployment %s of %s is still %s after %s", d.ID, d.Project, describeStage(stage), timeout)
+			}
+			return ctx.Err()
+		}
+
+		next, err := client.Deployment(ctx, d.ID)
+		if err != nil {
+			if ctx.Err() != nil {
+				continue
+			}
+			return err
+		}
+		if next.Project == "" {
+			next.Project = d.Project
+		}
+		d = next
+	}
+}
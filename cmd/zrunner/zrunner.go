@@ -16,7 +16,14 @@ limitations under the License.
 package zrunner
 
 import (
+	"github.com/Zettablock/zetta-go/cmd/zrunner/dev"
+	"github.com/Zettablock/zetta-go/cmd/zrunner/dlq"
+	"github.com/Zettablock/zetta-go/cmd/zrunner/fixtures"
+	"github.com/Zettablock/zetta-go/cmd/zrunner/gen"
 	"github.com/Zettablock/zetta-go/cmd/zrunner/pipeline"
+	"github.com/Zettablock/zetta-go/cmd/zrunner/secrets"
+	"github.com/Zettablock/zetta-go/cmd/zrunner/state"
+	"github.com/Zettablock/zetta-go/cmd/zrunner/versions"
 
 	"github.com/spf13/cobra"
 )
@@ -33,6 +40,25 @@ func init() {
 	Cmd.AddCommand(deployCmd)
 	Cmd.AddCommand(ormgenCmd)
 	Cmd.AddCommand(pipeline.Cmd)
+	Cmd.AddCommand(dev.Cmd)
+	Cmd.AddCommand(fixtures.Cmd)
+	Cmd.AddCommand(gen.Cmd)
+	Cmd.AddCommand(runCmd)
+	Cmd.AddCommand(state.Cmd)
+	Cmd.AddCommand(dlq.Cmd)
+	Cmd.AddCommand(buildCmd)
+	Cmd.AddCommand(upgradeZSourceCmd)
+	Cmd.AddCommand(versions.Cmd)
+	Cmd.AddCommand(rollbackCmd)
+	Cmd.AddCommand(undeployCmd)
+	Cmd.AddCommand(diffCmd)
+	Cmd.AddCommand(backfillCmd)
+	Cmd.AddCommand(reindexCmd)
+	Cmd.AddCommand(statusCmd)
+	Cmd.AddCommand(secrets.Cmd)
+
+	Cmd.PersistentFlags().String("project-dir", "", "project or workspace folder (default: the nearest folder with a project.yml or zrunner-workspace.yml, from the current folder up)")
+	Cmd.PersistentFlags().String("project", "", "name or path of the project of the workspace to use")
 
 	// Here you will define your flags and configuration settings.
 
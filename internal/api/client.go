@@ -0,0 +1,105 @@
+// Package api is a client of the hosted zrunner service API.
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const (
+	// DefaultBaseURL is the hosted zrunner service API.
+	DefaultBaseURL = "https://api.zettablock.com/api/v1/zrunner"
+
+	// baseURLEnv overrides DefaultBaseURL, e.g. to use a staging service.
+	baseURLEnv = "ZRUNNER_API_URL"
+)
+
+// Client calls the service API with an API key.
+type Client struct {
+	BaseURL string
+	APIKey  string
+	HTTP    *http.Client
+}
+
+// New returns a client of the service at DefaultBaseURL, or at
+// $ZRUNNER_API_URL if set.
+func New(apiKey string) *Client {
+	baseURL := DefaultBaseURL
+	if env := os.Getenv(baseURLEnv); env != "" {
+		baseURL = strings.TrimSuffix(env, "/")
+	}
+	return &Client{
+		BaseURL: baseURL,
+		APIKey:  apiKey,
+		HTTP:    &http.Client{},
+	}
+}
+
+// Error is a response of the service with an unexpected status.
+type Error struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("request failed: %s", e.Body)
+}
+
+// errResponse is wrapped by the errors decoding a response.
+var errResponse = errors.New("invalid response")
+
+// do sends in as JSON, if not nil, and decodes the response into out, if not
+// nil.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+
+	u := c.BaseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("X-API-KEY", c.APIKey)
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		b, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		return &Error{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(b))}
+	}
+	if out == nil {
+		return nil
+	}
+	// an empty body leaves out as it is
+	if err = json.NewDecoder(resp.Body).Decode(out); err != nil && err != io.EOF {
+		return fmt.Errorf("%w: %w", errResponse, err)
+	}
+	return nil
+}
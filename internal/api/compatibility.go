@@ -0,0 +1,18 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Zettablock/zetta-go/internal/compat"
+)
+
+// Compatibility returns the zsource versions supported by each range of CLI
+// versions, as the service knows them.
+func (c *Client) Compatibility(ctx context.Context) (compat.Matrix, error) {
+	var m compat.Matrix
+	if err := c.do(ctx, http.MethodGet, "/compatibility", nil, nil, &m); err != nil {
+		return nil, err
+	}
+	return m, m.Validate()
+}
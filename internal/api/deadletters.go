@@ -0,0 +1,25 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/Zettablock/zetta-go/internal/dlq"
+)
+
+// DeadLetters returns the dead-lettered invocations of a deployed project,
+// of one pipeline if not empty.
+func (c *Client) DeadLetters(ctx context.Context, org, project, pipeline string) ([]dlq.Entry, error) {
+	query := url.Values{"org": {org}, "project": {project}}
+	if pipeline != "" {
+		query.Set("pipeline", pipeline)
+	}
+	var resp struct {
+		DeadLetters []dlq.Entry `json:"dead_letters"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/dead-letters", query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.DeadLetters, nil
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Definition is the definition of the deployed version of a project: the
+// files it was deployed with, and its zsource version.
+type Definition struct {
+	Version        string `json:"version"`
+	Commit         string `json:"commit"`
+	ZSourceVersion string `json:"zsource_version"`
+	// Pipelines holds the pipeline.yml of every pipeline, by pipeline name,
+	// and Schemas the .sql files of the schemas folder, by file name.
+	Pipelines map[string]string `json:"pipelines"`
+	Schemas   map[string]string `json:"schemas"`
+}
+
+// Definition returns the definition of the deployed version of a project, or
+// nil if the project was never deployed.
+func (c *Client) Definition(ctx context.Context, org, project string) (*Definition, error) {
+	query := url.Values{"org": {org}, "project": {project}}
+	d := &Definition{}
+	if err := c.do(ctx, http.MethodGet, "/definition", query, nil, d); err != nil {
+		if e, ok := err.(*Error); ok && e.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return d, nil
+}
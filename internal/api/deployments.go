@@ -0,0 +1,69 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Stages a deployment, and each of its pipelines, goes through.
+const (
+	StageQueued    = "queued"
+	StageCloning   = "cloning"
+	StageBuilding  = "building"
+	StageMigrating = "migrating"
+	StageRunning   = "running"
+	StageFailed    = "failed"
+)
+
+// Deployment is the progress of a deployment of a project.
+type Deployment struct {
+	ID        string             `json:"id"`
+	Project   string             `json:"project"`
+	Stage     string             `json:"stage"`
+	Error     string             `json:"error,omitempty"`
+	Pipelines []PipelineProgress `json:"pipelines"`
+	UpdatedAt time.Time          `json:"updated_at"`
+}
+
+// PipelineProgress is the progress of a pipeline within a deployment.
+type PipelineProgress struct {
+	Name  string `json:"name"`
+	Stage string `json:"stage"`
+	Error string `json:"error,omitempty"`
+}
+
+// Done reports whether the deployment is over, running or failed.
+func (d *Deployment) Done() bool {
+	return d.Stage == StageRunning || d.Stage == StageFailed
+}
+
+// Deploy submits a deployment of a project. With describe, the response is
+// decoded into the returned deployment; if it cannot be, the deployment is
+// still submitted and is returned without ID.
+func (c *Client) Deploy(ctx context.Context, payload any, describe bool) (*Deployment, error) {
+	d := &Deployment{}
+	var out any
+	if describe {
+		out = d
+	}
+	err := c.do(ctx, http.MethodPost, "/pipeline", nil, payload, out)
+	switch {
+	case errors.Is(err, errResponse):
+		return &Deployment{}, nil
+	case err != nil:
+		return nil, err
+	}
+	return d, nil
+}
+
+// Deployment returns the progress of a deployment.
+func (c *Client) Deployment(ctx context.Context, id string) (*Deployment, error) {
+	d := &Deployment{}
+	if err := c.do(ctx, http.MethodGet, "/deployments/"+url.PathEscape(id), nil, nil, d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
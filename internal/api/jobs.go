@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// States of a job.
+const (
+	JobQueued  = "queued"
+	JobRunning = "running"
+	JobDone    = "done"
+	JobFailed  = "failed"
+)
+
+// Job is the progress of a deployed pipeline processing blocks again, for a
+// backfill or a reindex.
+type Job struct {
+	ID       string `json:"id"`
+	Pipeline string `json:"pipeline"`
+	State    string `json:"state"`
+	Error    string `json:"error,omitempty"`
+	// From and To are the range of blocks to process, and Block the last one
+	// processed, 0 if none yet.
+	From  int64 `json:"from"`
+	To    int64 `json:"to"`
+	Block int64 `json:"block"`
+}
+
+// Done reports whether the job is over, done or failed.
+func (j *Job) Done() bool {
+	return j.State == JobDone || j.State == JobFailed
+}
+
+// Backfill has a deployed pipeline process the blocks from, to again. A zero
+// to is the last block the pipeline processed.
+func (c *Client) Backfill(ctx context.Context, org, project, pipeline string, from, to int64) (*Job, error) {
+	req := struct {
+		Org      string `json:"org"`
+		Project  string `json:"project"`
+		Pipeline string `json:"pipeline"`
+		From     int64  `json:"from"`
+		To       int64  `json:"to,omitempty"`
+	}{org, project, pipeline, from, to}
+	j := &Job{}
+	if err := c.do(ctx, http.MethodPost, "/backfill", nil, req, j); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// Reindex has a deployed pipeline process every block again, from its start
+// block. With truncate, the rows of its tables are deleted first.
+func (c *Client) Reindex(ctx context.Context, org, project, pipeline string, truncate bool) (*Job, error) {
+	req := struct {
+		Org      string `json:"org"`
+		Project  string `json:"project"`
+		Pipeline string `json:"pipeline"`
+		Truncate bool   `json:"truncate"`
+	}{org, project, pipeline, truncate}
+	j := &Job{}
+	if err := c.do(ctx, http.MethodPost, "/reindex", nil, req, j); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// Job returns the progress of a job.
+func (c *Client) Job(ctx context.Context, id string) (*Job, error) {
+	j := &Job{}
+	if err := c.do(ctx, http.MethodGet, "/jobs/"+url.PathEscape(id), nil, nil, j); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
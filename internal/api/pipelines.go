@@ -0,0 +1,68 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// DeletePipeline undeploys a pipeline of a deployed project.
+func (c *Client) DeletePipeline(ctx context.Context, org, project, pipeline string) error {
+	query := url.Values{"org": {org}, "project": {project}, "pipeline": {pipeline}}
+	return c.do(ctx, http.MethodDelete, "/pipeline", query, nil, nil)
+}
+
+// RemotePipeline is a pipeline of a deployed project.
+type RemotePipeline struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Stage   string `json:"stage"`
+	// Block is the last block processed, and Paused set while the pipeline
+	// is paused.
+	Block  int64  `json:"block,omitempty"`
+	Paused *Pause `json:"paused,omitempty"`
+}
+
+// Pause records who paused a pipeline, when and why.
+type Pause struct {
+	By     string    `json:"by"`
+	Reason string    `json:"reason"`
+	At     time.Time `json:"at"`
+}
+
+// Pipelines returns the deployed pipelines of a project.
+func (c *Client) Pipelines(ctx context.Context, org, project string) ([]RemotePipeline, error) {
+	query := url.Values{"org": {org}, "project": {project}}
+	var resp struct {
+		Pipelines []RemotePipeline `json:"pipelines"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/pipelines", query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Pipelines, nil
+}
+
+// PausePipeline stops a deployed pipeline, without undeploying it, recording
+// who paused it and why.
+func (c *Client) PausePipeline(ctx context.Context, org, project, pipeline, by, reason string) error {
+	req := struct {
+		Org      string `json:"org"`
+		Project  string `json:"project"`
+		Pipeline string `json:"pipeline"`
+		By       string `json:"by"`
+		Reason   string `json:"reason"`
+	}{org, project, pipeline, by, reason}
+	return c.do(ctx, http.MethodPost, "/pipeline/pause", nil, req, nil)
+}
+
+// ResumePipeline restarts a paused pipeline where it stopped.
+func (c *Client) ResumePipeline(ctx context.Context, org, project, pipeline, by string) error {
+	req := struct {
+		Org      string `json:"org"`
+		Project  string `json:"project"`
+		Pipeline string `json:"pipeline"`
+		By       string `json:"by"`
+	}{org, project, pipeline, by}
+	return c.do(ctx, http.MethodPost, "/pipeline/resume", nil, req, nil)
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Zettablock/zetta-go/internal/secrets"
+)
+
+// Secret is a secret of a deployed pipeline. Its value is never returned.
+type Secret struct {
+	Name      string    `json:"name"`
+	UpdatedBy string    `json:"updated_by"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SecretsKey returns the PEM encoded public key to encrypt secrets with.
+func (c *Client) SecretsKey(ctx context.Context) ([]byte, error) {
+	var resp struct {
+		PublicKey string `json:"public_key"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/secrets/key", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return []byte(resp.PublicKey), nil
+}
+
+// Secrets returns the secrets set for a pipeline of a project.
+func (c *Client) Secrets(ctx context.Context, org, project, pipeline string) ([]Secret, error) {
+	query := url.Values{"org": {org}, "project": {project}, "pipeline": {pipeline}}
+	var resp struct {
+		Secrets []Secret `json:"secrets"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/secrets", query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Secrets, nil
+}
+
+// SetSecret sets, or replaces, a secret of a pipeline with an encrypted
+// value.
+func (c *Client) SetSecret(ctx context.Context, org, project, pipeline, name, by string, value *secrets.Sealed) error {
+	req := struct {
+		Org      string          `json:"org"`
+		Project  string          `json:"project"`
+		Pipeline string          `json:"pipeline"`
+		Name     string          `json:"name"`
+		By       string          `json:"by"`
+		Value    *secrets.Sealed `json:"value"`
+	}{org, project, pipeline, name, by, value}
+	return c.do(ctx, http.MethodPut, "/secrets", nil, req, nil)
+}
+
+// UnsetSecret deletes a secret of a pipeline.
+func (c *Client) UnsetSecret(ctx context.Context, org, project, pipeline, name string) error {
+	query := url.Values{"org": {org}, "project": {project}, "pipeline": {pipeline}, "name": {name}}
+	return c.do(ctx, http.MethodDelete, "/secrets", query, nil, nil)
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Version is a deployed version of a project.
+type Version struct {
+	Version        string    `json:"version"`
+	Commit         string    `json:"commit"`
+	ZSourceVersion string    `json:"zsource_version"`
+	DeployedAt     time.Time `json:"deployed_at"`
+	// Current is set on the version that is running.
+	Current bool `json:"current"`
+}
+
+// Versions returns the versions of a project deployed so far, oldest first.
+func (c *Client) Versions(ctx context.Context, org, project string) ([]Version, error) {
+	query := url.Values{"org": {org}, "project": {project}}
+	var resp struct {
+		Versions []Version `json:"versions"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/versions", query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Versions, nil
+}
+
+// Rollback redeploys a version of a project deployed before. As for Deploy,
+// with describe the response is decoded into the returned deployment; if it
+// cannot be, the rollback is still submitted and is returned without ID.
+func (c *Client) Rollback(ctx context.Context, org, project, version string, describe bool) (*Deployment, error) {
+	req := struct {
+		Org     string `json:"org"`
+		Project string `json:"project"`
+		Version string `json:"version"`
+	}{org, project, version}
+	d := &Deployment{}
+	var out any
+	if describe {
+		out = d
+	}
+	err := c.do(ctx, http.MethodPost, "/rollback", nil, req, out)
+	switch {
+	case errors.Is(err, errResponse):
+		return &Deployment{}, nil
+	case err != nil:
+		return nil, err
+	}
+	return d, nil
+}
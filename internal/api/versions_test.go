@@ -0,0 +1,128 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTestClient returns a client of a test server answering with handler.
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return &Client{BaseURL: srv.URL, APIKey: "key", HTTP: srv.Client()}
+}
+
+func TestVersions(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/versions" {
+			t.Errorf("request = %s %s, want GET /versions", r.Method, r.URL.Path)
+		}
+		if got := r.URL.Query().Get("org"); got != "acme" {
+			t.Errorf("org = %q, want acme", got)
+		}
+		if got := r.URL.Query().Get("project"); got != "demo" {
+			t.Errorf("project = %q, want demo", got)
+		}
+		if got := r.Header.Get("X-API-KEY"); got != "key" {
+			t.Errorf("X-API-KEY = %q, want key", got)
+		}
+		w.Write([]byte(`{"versions": [
+			{"version": "0.1.0", "commit": "abc", "zsource_version": "v1.2.0", "deployed_at": "2024-05-01T10:00:00Z"},
+			{"version": "0.2.0", "commit": "def", "zsource_version": "v1.3.0", "deployed_at": "2024-06-01T10:00:00Z", "current": true}
+		]}`))
+	})
+
+	versions, err := client.Versions(context.Background(), "acme", "demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("got %d versions, want 2", len(versions))
+	}
+	if v := versions[0]; v.Version != "0.1.0" || v.Commit != "abc" || v.ZSourceVersion != "v1.2.0" || v.Current {
+		t.Errorf("versions[0] = %+v", v)
+	}
+	if v := versions[1]; v.Version != "0.2.0" || !v.Current || v.DeployedAt.Month() != 6 {
+		t.Errorf("versions[1] = %+v", v)
+	}
+}
+
+func TestVersionsError(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "project demo not found", http.StatusNotFound)
+	})
+
+	_, err := client.Versions(context.Background(), "acme", "demo")
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("err = %v, want *Error", err)
+	}
+	if apiErr.StatusCode != http.StatusNotFound || apiErr.Body != "project demo not found" {
+		t.Errorf("err = %+v", apiErr)
+	}
+}
+
+func TestRollback(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/rollback" {
+			t.Errorf("request = %s %s, want POST /rollback", r.Method, r.URL.Path)
+		}
+		var req struct {
+			Org, Project, Version string
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		if req.Org != "acme" || req.Project != "demo" || req.Version != "0.1.0" {
+			t.Errorf("request = %+v", req)
+		}
+		w.Write([]byte(`{"id": "d-1", "project": "demo", "stage": "building"}`))
+	})
+
+	d, err := client.Rollback(context.Background(), "acme", "demo", "0.1.0", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.ID != "d-1" || d.Project != "demo" || d.Stage != "building" {
+		t.Errorf("deployment = %+v", d)
+	}
+}
+
+func TestRollbackUndecodable(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("rollback accepted"))
+	})
+
+	for _, describe := range []bool{true, false} {
+		d, err := client.Rollback(context.Background(), "acme", "demo", "0.1.0", describe)
+		if err != nil {
+			t.Fatalf("describe %v: %v", describe, err)
+		}
+		if d.ID != "" {
+			t.Errorf("describe %v: ID = %q, want none", describe, d.ID)
+		}
+	}
+}
+
+func TestRollbackError(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "version 0.1.0 not found", http.StatusBadRequest)
+	})
+
+	d, err := client.Rollback(context.Background(), "acme", "demo", "0.1.0", true)
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("err = %v, want *Error", err)
+	}
+	if apiErr.StatusCode != http.StatusBadRequest || apiErr.Body != "version 0.1.0 not found" {
+		t.Errorf("err = %+v", apiErr)
+	}
+	if d != nil {
+		t.Errorf("deployment = %+v, want nil", d)
+	}
+}
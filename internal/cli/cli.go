@@ -0,0 +1,83 @@
+// Package cli reads the flags zrunner commands share: --project-dir and
+// --project, which select the projects a command applies to, and --api-key.
+package cli
+
+import (
+	"errors"
+
+	"github.com/Zettablock/zetta-go/internal"
+	"github.com/Zettablock/zetta-go/internal/api"
+
+	"github.com/spf13/cobra"
+)
+
+// ErrNoAPIKey is returned when neither --api-key nor project.yml gives an
+// API key.
+var ErrNoAPIKey = errors.New("api-key is required")
+
+func projectFlags(cmd *cobra.Command) (string, string, error) {
+	projectDir, err := cmd.Flags().GetString("project-dir")
+	if err != nil {
+		return "", "", err
+	}
+	projectName, err := cmd.Flags().GetString("project")
+	if err != nil {
+		return "", "", err
+	}
+	return projectDir, projectName, nil
+}
+
+// ProjectRoot returns the folder of the project the command applies to.
+func ProjectRoot(cmd *cobra.Command) (string, error) {
+	projectDir, projectName, err := projectFlags(cmd)
+	if err != nil {
+		return "", err
+	}
+	return internal.FindProjectRoot(projectDir, projectName)
+}
+
+// LoadProject reads the configuration of the project the command applies
+// to.
+func LoadProject(cmd *cobra.Command) (internal.ProjectConfig, error) {
+	projectDir, projectName, err := projectFlags(cmd)
+	if err != nil {
+		return internal.ProjectConfig{}, err
+	}
+	return internal.LoadProjectConfig(projectDir, projectName)
+}
+
+// LoadProjects reads the configuration of every project the command applies
+// to.
+func LoadProjects(cmd *cobra.Command) ([]internal.ProjectConfig, error) {
+	projectDir, projectName, err := projectFlags(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return internal.LoadProjectConfigs(projectDir, projectName)
+}
+
+// APIKey returns the API key of --api-key, or else the apikey of the
+// project.yml of config.
+func APIKey(cmd *cobra.Command, config *internal.ProjectConfig) (string, error) {
+	apiKey, err := cmd.Flags().GetString("api-key")
+	if err != nil {
+		return "", err
+	}
+	if apiKey == "" {
+		apiKey = config.ApiKey
+	}
+	if apiKey == "" {
+		return "", ErrNoAPIKey
+	}
+	return apiKey, nil
+}
+
+// APIClient returns a client of the hosted service with the API key of
+// APIKey.
+func APIClient(cmd *cobra.Command, config *internal.ProjectConfig) (*api.Client, error) {
+	apiKey, err := APIKey(cmd, config)
+	if err != nil {
+		return nil, err
+	}
+	return api.New(apiKey), nil
+}
@@ -0,0 +1,128 @@
+// Package compat maps zetta-go versions to the zsource versions they
+// support, with semver constraints.
+package compat
+
+import (
+	_ "embed"
+	"fmt"
+
+	"github.com/Masterminds/semver/v3"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed compatibility.yml
+var embedded []byte
+
+// Entry gives the zsource versions supported by a range of CLI versions.
+type Entry struct {
+	CLI         string `yaml:"cli" json:"cli"`
+	ZSource     string `yaml:"zsource" json:"zsource"`
+	Recommended string `yaml:"recommended" json:"recommended"`
+}
+
+// Matrix is a list of entries, the first matching one applies.
+type Matrix []Entry
+
+// IncompatibleError is a zsource version the CLI version does not support.
+type IncompatibleError struct {
+	CLI       string
+	ZSource   string
+	Supported string
+}
+
+func (e *IncompatibleError) Error() string {
+	return fmt.Sprintf("zsource %s is not supported by zetta-go %s, which supports zsource %s", e.ZSource, e.CLI, e.Supported)
+}
+
+// Embedded returns the matrix shipped with the CLI.
+func Embedded() Matrix {
+	m, err := Parse(embedded)
+	if err != nil {
+		panic(fmt.Sprintf("invalid embedded compatibility matrix: %v", err))
+	}
+	return m
+}
+
+// Parse reads a matrix and validates its constraints.
+func Parse(data []byte) (Matrix, error) {
+	var m Matrix
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, m.Validate()
+}
+
+// Validate checks that every constraint and recommended version parses.
+func (m Matrix) Validate() error {
+	for _, e := range m {
+		if _, err := semver.NewConstraint(e.CLI); err != nil {
+			return fmt.Errorf("cli constraint %q: %w", e.CLI, err)
+		}
+		if _, err := semver.NewConstraint(e.ZSource); err != nil {
+			return fmt.Errorf("zsource constraint %q: %w", e.ZSource, err)
+		}
+		if _, err := semver.NewVersion(e.Recommended); err != nil {
+			return fmt.Errorf("recommended version %q: %w", e.Recommended, err)
+		}
+	}
+	return nil
+}
+
+// Lookup returns the entry of a CLI version, or nil if the matrix does not
+// know it.
+func (m Matrix) Lookup(cli string) (*Entry, error) {
+	v, err := semver.NewVersion(cli)
+	if err != nil {
+		return nil, fmt.Errorf("invalid zetta-go version %q", cli)
+	}
+	for i, e := range m {
+		c, err := semver.NewConstraint(e.CLI)
+		if err != nil {
+			return nil, err
+		}
+		if c.Check(v) {
+			return &m[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// Allows reports whether the entry supports a zsource version.
+func (e *Entry) Allows(zsource string) (bool, error) {
+	v, err := semver.NewVersion(zsource)
+	if err != nil {
+		return false, fmt.Errorf("invalid zsource version %q", zsource)
+	}
+	c, err := semver.NewConstraint(e.ZSource)
+	if err != nil {
+		return false, err
+	}
+	return c.Check(v), nil
+}
+
+// Check returns an IncompatibleError if the CLI version does not support the
+// zsource version. CLI versions the matrix does not know are not checked.
+func (m Matrix) Check(cli, zsource string) error {
+	e, err := m.Lookup(cli)
+	if err != nil || e == nil {
+		return err
+	}
+	ok, err := e.Allows(zsource)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return &IncompatibleError{CLI: cli, ZSource: zsource, Supported: e.ZSource}
+	}
+	return nil
+}
+
+// Recommended returns the zsource version new projects of a CLI version
+// should require, or "" if the matrix does not know the CLI version.
+func (m Matrix) Recommended(cli string) string {
+	e, err := m.Lookup(cli)
+	if err != nil || e == nil {
+		return ""
+	}
+	return e.Recommended
+}
@@ -0,0 +1,364 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type ProjectConfig struct {
+	// Root is the absolute path of the project folder, and Dir its name.
+	Root string `yaml:"-"`
+	Dir  string
+	// Path is the project folder relative to the repository, and Workspace
+	// the root of its workspace, if any.
+	Path           string `yaml:"-"`
+	Workspace      string `yaml:"-"`
+	Org            string
+	Kind           string
+	Network        string
+	Version        string
+	Name           string
+	ApiKey         string
+	GithubRepo     string `yaml:"githubRepo"`
+	Pat            string
+	ZSourceVersion string
+	// PipelinePaths lists the pipeline folders, or globs of them, relative
+	// to the project. Without it, every folder with a pipeline.yml is one.
+	PipelinePaths []string         `yaml:"pipelines"`
+	Pipelines     []PipelineConfig `yaml:"-"`
+	Snapshot      SnapshotConfig
+}
+
+type SnapshotConfig struct {
+	// Exclude lists the columns left out of snapshots, as column for every
+	// table or table.column, e.g. serial ids and insertion timestamps.
+	Exclude []string
+}
+
+type PipelineConfig struct {
+	Name          string
+	Dir           string
+	Source        SourceConfig
+	EventHandlers []EventHandlerConfig `yaml:"eventHandlers"`
+	BlockHandlers []BlockHandlerConfig `yaml:"blockHandlers"`
+	ReorgHandler  string               `yaml:"reorgHandler"`
+	// Parallelism is the number of blocks run processes concurrently.
+	Parallelism int
+	Retry       *RetryConfig
+	// Env is set in the environment of the handlers.
+	Env map[string]EnvValue
+}
+
+type SourceConfig struct {
+	StartBlock int64 `yaml:"startBlock"`
+	Type       string
+	Rpc        string
+	Addresses  []string
+	AbiFile    string `yaml:"abiFile"`
+}
+
+type EventHandlerConfig struct {
+	Event   string
+	Handler string
+	// Ordered handlers are invoked in block order even when blocks are
+	// processed concurrently.
+	Ordered bool
+}
+
+type BlockHandlerConfig struct {
+	Handler string
+	Ordered bool
+}
+
+// ErrNoProject is returned when no project.yml is found.
+var ErrNoProject = errors.New("no project.yml found in this folder or any parent folder, run the command inside a zrunner project or use --project-dir")
+
+// ignoredDirs are never searched for pipelines, along with hidden folders.
+var ignoredDirs = map[string]bool{
+	"vendor":       true,
+	"dao":          true,
+	"node_modules": true,
+	"testdata":     true,
+}
+
+// FindProjectRoots returns the folders of the projects a command applies to.
+// dir is the folder of a project or a workspace, by default the current
+// folder or its nearest parent with either. name selects a project of the
+// workspace. Without it, a command run inside a project applies to that
+// project, and one run elsewhere in a workspace to all of its projects.
+func FindProjectRoots(dir, name string) ([]string, error) {
+	exact := dir != ""
+	if !exact {
+		dir = "."
+	}
+	start, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	ws, err := FindWorkspace(start, exact)
+	if err != nil {
+		return nil, err
+	}
+
+	if name != "" {
+		if ws == nil {
+			return nil, fmt.Errorf("no %s found, selecting a project needs one", workspaceYml)
+		}
+		p, err := ws.Project(name)
+		if err != nil {
+			return nil, err
+		}
+		return []string{p.Root}, nil
+	}
+
+	// look for the project the folder is in, without leaving the workspace
+	for dir := start; ; {
+		if _, err = os.Stat(filepath.Join(dir, projectYml)); err == nil {
+			return []string{dir}, nil
+		}
+		parent := filepath.Dir(dir)
+		if exact || parent == dir || (ws != nil && dir == ws.Root) {
+			break
+		}
+		dir = parent
+	}
+
+	switch {
+	case ws != nil:
+		roots := make([]string, 0, len(ws.Projects))
+		for _, p := range ws.Projects {
+			roots = append(roots, p.Root)
+		}
+		return roots, nil
+	case exact:
+		return nil, fmt.Errorf("no %s or %s found in %s", projectYml, workspaceYml, dir)
+	default:
+		return nil, ErrNoProject
+	}
+}
+
+// FindProjectRoot is FindProjectRoots for commands that apply to a single
+// project.
+func FindProjectRoot(dir, name string) (string, error) {
+	roots, err := FindProjectRoots(dir, name)
+	if err != nil {
+		return "", err
+	}
+	if len(roots) > 1 {
+		return "", fmt.Errorf("the workspace has %d projects, choose one with --project", len(roots))
+	}
+	return roots[0], nil
+}
+
+// LoadProjectConfigs reads the configuration of every project found by
+// FindProjectRoots.
+func LoadProjectConfigs(dir, name string) ([]ProjectConfig, error) {
+	roots, err := FindProjectRoots(dir, name)
+	if err != nil {
+		return nil, err
+	}
+	var configs []ProjectConfig
+	for _, root := range roots {
+		cfg, err := readProjectConfig(root)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, cfg)
+	}
+	return configs, nil
+}
+
+// LoadProjectConfig reads project.yml and every pipeline.yml of the project
+// found by FindProjectRoot.
+func LoadProjectConfig(dir, name string) (ProjectConfig, error) {
+	root, err := FindProjectRoot(dir, name)
+	if err != nil {
+		return ProjectConfig{}, err
+	}
+	return readProjectConfig(root)
+}
+
+func readProjectConfig(root string) (ProjectConfig, error) {
+	projectCfg := ProjectConfig{}
+	data, err := os.ReadFile(filepath.Join(root, projectYml))
+	if err != nil {
+		return projectCfg, err
+	}
+	err = yaml.Unmarshal(data, &projectCfg)
+	if err != nil {
+		return ProjectConfig{}, err
+	}
+	projectCfg.Root = root
+	projectCfg.Dir = filepath.Base(root)
+
+	ws, err := FindWorkspace(root, false)
+	if err != nil {
+		return projectCfg, err
+	}
+	if ws != nil {
+		projectCfg.Workspace = ws.Root
+	}
+	if projectCfg.Path, err = repoPath(root, ws); err != nil {
+		return projectCfg, err
+	}
+
+	pipelineCfgs, err := findPipelineConfig(root, projectCfg.PipelinePaths)
+
+	if len(pipelineCfgs) == 0 || err != nil {
+		return projectCfg, err
+	}
+
+	dirs := make(map[string]string)
+
+	for _, cfgLoc := range pipelineCfgs {
+		cfg, err := readPipelineConfig(cfgLoc)
+		if err != nil {
+			return projectCfg, err
+		}
+		// pipelines are built and located relative to the project root
+		if cfg.Dir, err = filepath.Rel(root, filepath.Dir(cfgLoc)); err != nil {
+			return projectCfg, err
+		}
+		if other, ok := dirs[cfg.Name]; ok {
+			return projectCfg, fmt.Errorf("pipelines %s and %s are both named %q", other, cfg.Dir, cfg.Name)
+		}
+		dirs[cfg.Name] = cfg.Dir
+		projectCfg.Pipelines = append(projectCfg.Pipelines, cfg)
+	}
+
+	return projectCfg, nil
+}
+
+// Pipeline returns the pipeline of the project with the given name.
+func (c *ProjectConfig) Pipeline(name string) (PipelineConfig, error) {
+	for _, pipeline := range c.Pipelines {
+		if pipeline.Name == name {
+			return pipeline, nil
+		}
+	}
+	return PipelineConfig{}, fmt.Errorf("pipeline %s not found", name)
+}
+
+// SelectPipelines returns the pipelines of the project with the given names,
+// or all of them if names is empty.
+func (c *ProjectConfig) SelectPipelines(names []string) ([]PipelineConfig, error) {
+	if len(names) == 0 {
+		return c.Pipelines, nil
+	}
+	selected := make([]PipelineConfig, 0, len(names))
+	for _, name := range names {
+		pipeline, err := c.Pipeline(name)
+		if err != nil {
+			return nil, err
+		}
+		selected = append(selected, pipeline)
+	}
+	return selected, nil
+}
+
+func readPipelineConfig(cfgLoc string) (PipelineConfig, error) {
+	data, err := os.ReadFile(cfgLoc)
+	if err != nil {
+		return PipelineConfig{}, err
+	}
+
+	cfg, err := ParsePipelineConfig(data)
+	if err != nil {
+		return cfg, err
+	}
+
+	cfg.Dir = filepath.Base(filepath.Dir(cfgLoc))
+	return cfg, nil
+}
+
+// ParsePipelineConfig reads the content of a pipeline.yml. Dir is left
+// empty.
+func ParsePipelineConfig(data []byte) (PipelineConfig, error) {
+	cfg := PipelineConfig{}
+	err := yaml.Unmarshal(data, &cfg)
+	return cfg, err
+}
+
+// findPipelineConfig returns the pipeline.yml files of the project at root,
+// sorted: those of the folders patterns match, or else all of them.
+func findPipelineConfig(root string, patterns []string) ([]string, error) {
+	if len(patterns) > 0 {
+		return matchPipelineConfig(root, patterns)
+	}
+
+	var configFiles []string
+	err := filepath.Walk(root, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && path != root {
+			if ignoredDirs[info.Name()] || strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			// another project of the workspace
+			if _, err := os.Stat(filepath.Join(path, projectYml)); err == nil {
+				return filepath.SkipDir
+			}
+		}
+		if !info.IsDir() && info.Name() == pipelineYml {
+			configFiles = append(configFiles, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sortByDir(configFiles)
+	return configFiles, nil
+}
+
+// matchPipelineConfig returns the pipeline.yml files of the folders that
+// patterns match. A pattern that matches no pipeline folder is an error.
+func matchPipelineConfig(root string, patterns []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var configFiles []string
+	for _, pattern := range patterns {
+		if filepath.IsAbs(pattern) {
+			return nil, fmt.Errorf("pipelines: %s should be relative to the project folder", pattern)
+		}
+		matches, err := filepath.Glob(filepath.Join(root, filepath.FromSlash(pattern)))
+		if err != nil {
+			return nil, fmt.Errorf("pipelines: %s: %w", pattern, err)
+		}
+		found := false
+		for _, match := range matches {
+			if filepath.Base(match) != pipelineYml {
+				match = filepath.Join(match, pipelineYml)
+			}
+			info, err := os.Stat(match)
+			if err != nil || info.IsDir() {
+				continue
+			}
+			found = true
+			if !seen[match] {
+				seen[match] = true
+				configFiles = append(configFiles, match)
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("pipelines: %s matches no folder with a %s", pattern, pipelineYml)
+		}
+	}
+
+	sortByDir(configFiles)
+	return configFiles, nil
+}
+
+// sortByDir sorts files by folder, so that a pipeline comes before those
+// nested in it.
+func sortByDir(files []string) {
+	sort.Slice(files, func(i, j int) bool { return filepath.Dir(files[i]) < filepath.Dir(files[j]) })
+}
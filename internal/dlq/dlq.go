@@ -0,0 +1,239 @@
+// Package dlq stores the handler invocations that failed for good, so that
+// their inputs are not lost.
+package dlq
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Zettablock/zetta-go/internal/fixtures"
+	"github.com/Zettablock/zetta-go/internal/localdb"
+)
+
+const dlqDir = "dlq"
+
+// Entry is a dead-lettered invocation: the record a handler failed on, and
+// how. Dead letters fetched from the hosted service use the same model.
+type Entry struct {
+	ID       string          `json:"id"`
+	Pipeline string          `json:"pipeline"`
+	Handler  string          `json:"handler"`
+	Block    int64           `json:"block"`
+	Record   fixtures.Record `json:"record"`
+	Error    string          `json:"error"`
+	Attempts int             `json:"attempts"`
+	Time     time.Time       `json:"time"`
+}
+
+// Store appends entries to .zrunner/dlq/<pipeline>.ndjson, one JSON entry
+// per line. It is safe for concurrent use.
+type Store struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// Open returns the dead-letter store of the project at root.
+func Open(root string) *Store {
+	return &Store{dir: filepath.Join(root, localdb.StateDir, dlqDir)}
+}
+
+// Dir is the folder of the store.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// NewID returns an identifier for an entry, derived from what failed and
+// when.
+func NewID(entry Entry) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00%d", entry.Pipeline, entry.Handler, entry.Record.Key(), entry.Time.UnixNano())
+	return hex.EncodeToString(h.Sum(nil))[:12]
+}
+
+// Append adds an entry to the file of its pipeline. Entries without an ID
+// get one.
+func (s *Store) Append(entry Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry.ID == "" {
+		entry.ID = NewID(entry)
+	}
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.file(entry.Pipeline), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if err = json.NewEncoder(f).Encode(&entry); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// List returns the entries of a pipeline, or of all pipelines if pipeline is
+// empty, oldest first.
+func (s *Store) List(pipeline string) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	files := []string{s.file(pipeline)}
+	if pipeline == "" {
+		var err error
+		if files, err = filepath.Glob(filepath.Join(s.dir, "*.ndjson")); err != nil {
+			return nil, err
+		}
+	}
+
+	var entries []Entry
+	for _, file := range files {
+		read, err := readFile(file)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, read...)
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.Before(entries[j].Time) })
+	return entries, nil
+}
+
+// Get returns the entry with the given ID, or a prefix of it.
+func (s *Store) Get(id string) (Entry, error) {
+	entries, err := s.List("")
+	if err != nil {
+		return Entry{}, err
+	}
+	return Find(entries, id)
+}
+
+// Find returns the entry with the given ID, or a prefix of it, among
+// entries.
+func Find(entries []Entry, id string) (Entry, error) {
+	var found []Entry
+	for _, entry := range entries {
+		if entry.ID == id {
+			return entry, nil
+		}
+		if id != "" && strings.HasPrefix(entry.ID, id) {
+			found = append(found, entry)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return Entry{}, fmt.Errorf("dead letter %s not found", id)
+	case 1:
+		return found[0], nil
+	default:
+		return Entry{}, fmt.Errorf("dead letter ID %s is ambiguous", id)
+	}
+}
+
+// Remove deletes the entries with the given IDs.
+func (s *Store) Remove(ids ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	remove := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		remove[id] = true
+	}
+
+	files, err := filepath.Glob(filepath.Join(s.dir, "*.ndjson"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		entries, err := readFile(file)
+		if err != nil {
+			return err
+		}
+		var kept []Entry
+		for _, entry := range entries {
+			if !remove[entry.ID] {
+				kept = append(kept, entry)
+			}
+		}
+		if len(kept) == len(entries) {
+			continue
+		}
+		if len(kept) == 0 {
+			if err = os.Remove(file); err != nil {
+				return err
+			}
+			continue
+		}
+		if err = writeFile(file, kept); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readFile(file string) ([]Entry, error) {
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		entry := Entry{}
+		if err = json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", file, line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// writeFile replaces file atomically with entries.
+func writeFile(file string, entries []Entry) error {
+	tmp, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	buffered := bufio.NewWriter(tmp)
+	encoder := json.NewEncoder(buffered)
+	for i := range entries {
+		if err = encoder.Encode(&entries[i]); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err = buffered.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+func (s *Store) file(pipeline string) string {
+	return filepath.Join(s.dir, pipeline+".ndjson")
+}
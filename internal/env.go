@@ -0,0 +1,190 @@
+package internal
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DotEnvFile holds the values of the secrets for local runs, in the project
+// folder, or in a pipeline folder for that pipeline only.
+const DotEnvFile = ".env"
+
+// envNamePattern is what environment variable and secret names may contain.
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// EnvValue is a variable of the env section of pipeline.yml: a plain value,
+// or a reference to a secret of the pipeline set with zrunner secrets set.
+//
+//	env:
+//	  REGISTRY_URL: https://registry.example.com
+//	  REGISTRY_API_KEY:
+//	    secret: registry_api_key
+type EnvValue struct {
+	Value  string `yaml:"value" json:"value,omitempty"`
+	Secret string `yaml:"secret" json:"secret,omitempty"`
+}
+
+func (v *EnvValue) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		v.Value = node.Value
+		return nil
+	}
+	type plain EnvValue
+	return node.Decode((*plain)(v))
+}
+
+func (v EnvValue) MarshalYAML() (any, error) {
+	if v.Secret == "" {
+		return v.Value, nil
+	}
+	return map[string]string{"secret": v.Secret}, nil
+}
+
+// CheckEnv validates the env section of a pipeline.
+func (c *PipelineConfig) CheckEnv() error {
+	for _, name := range c.EnvNames() {
+		v := c.Env[name]
+		if !envNamePattern.MatchString(name) {
+			return fmt.Errorf("env: %s is not a valid variable name", name)
+		}
+		if v.Secret != "" && v.Value != "" {
+			return fmt.Errorf("env: %s should have a value or a secret, not both", name)
+		}
+		if v.Secret != "" && !ValidSecretName(v.Secret) {
+			return fmt.Errorf("env: %s: %s is not a valid secret name", name, v.Secret)
+		}
+	}
+	return nil
+}
+
+// ValidSecretName reports whether a secret may have this name.
+func ValidSecretName(name string) bool {
+	return envNamePattern.MatchString(name)
+}
+
+// EnvNames returns the variables of the env section, sorted.
+func (c *PipelineConfig) EnvNames() []string {
+	names := make([]string, 0, len(c.Env))
+	for name := range c.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Secrets returns the secrets the env section references, sorted.
+func (c *PipelineConfig) Secrets() []string {
+	var secrets []string
+	seen := make(map[string]bool)
+	for _, name := range c.EnvNames() {
+		if s := c.Env[name].Secret; s != "" && !seen[s] {
+			seen[s] = true
+			secrets = append(secrets, s)
+		}
+	}
+	sort.Strings(secrets)
+	return secrets
+}
+
+// LocalEnv returns the environment of the pipelines for local runs, as
+// NAME=value, taking the values of their secrets from the .env of their
+// folder or of the project folder. The pipelines run in a single process,
+// so they may not set a variable to different values.
+func LocalEnv(root string, pipelines []PipelineConfig) ([]string, error) {
+	var env []string
+	values := make(map[string]string)
+	setBy := make(map[string]string)
+	for _, pipeline := range pipelines {
+		if len(pipeline.Env) == 0 {
+			continue
+		}
+		own, err := pipeline.localEnv(root)
+		if err != nil {
+			return nil, fmt.Errorf("pipeline %s: %w", pipeline.Name, err)
+		}
+		for _, name := range pipeline.EnvNames() {
+			if other, ok := setBy[name]; ok {
+				if values[name] != own[name] {
+					return nil, fmt.Errorf("pipelines %s and %s set %s to different values, run them separately with --pipeline", other, pipeline.Name, name)
+				}
+				continue
+			}
+			setBy[name], values[name] = pipeline.Name, own[name]
+			env = append(env, name+"="+own[name])
+		}
+	}
+	return env, nil
+}
+
+func (c *PipelineConfig) localEnv(root string) (map[string]string, error) {
+	if err := c.CheckEnv(); err != nil {
+		return nil, err
+	}
+	secrets, err := ReadDotEnv(filepath.Join(root, DotEnvFile))
+	if err != nil {
+		return nil, err
+	}
+	own, err := ReadDotEnv(filepath.Join(root, c.Dir, DotEnvFile))
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range own {
+		secrets[name] = value
+	}
+
+	env := make(map[string]string, len(c.Env))
+	for name, v := range c.Env {
+		value := v.Value
+		if v.Secret != "" {
+			var ok bool
+			if value, ok = secrets[v.Secret]; !ok {
+				return nil, fmt.Errorf("secret %s of %s is not in %s, add a line %s=value", v.Secret, name, DotEnvFile, v.Secret)
+			}
+		}
+		env[name] = value
+	}
+	return env, nil
+}
+
+// ReadDotEnv reads a .env file of NAME=value lines. Blank lines, comments and
+// an export prefix are ignored, and quotes around values are removed. A
+// missing file is empty.
+func ReadDotEnv(path string) (map[string]string, error) {
+	values := make(map[string]string)
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return values, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		name, value, ok := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if !ok || !envNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("%s:%d: expected NAME=value", path, n)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		values[name] = value
+	}
+	return values, scanner.Err()
+}
@@ -0,0 +1,455 @@
+// Package fixtures defines the on-disk format of captured chain data used to
+// replay pipelines locally.
+//
+// A fixture file is NDJSON, optionally gzip compressed, where every line is a
+// Record. Data holds the row exactly as the matching zsource DAO type encodes
+// it, keyed by column name, so it can both be loaded into a source table and
+// decoded into ethereum.Block, ethereum.Log, etc.
+package fixtures
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Record is one line of a fixture file.
+type Record struct {
+	Chain string          `json:"chain"`
+	Type  string          `json:"type"`
+	Block int64           `json:"block"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// Types of records.
+const (
+	TypeBlock       = "block"
+	TypeTransaction = "transaction"
+	TypeLog         = "log"
+	TypeWithdrawal  = "withdrawal"
+)
+
+type tableSpec struct {
+	table string
+	// block is the column of Data holding the record block number.
+	block string
+	// key identifies a record among those of the same type.
+	key []string
+}
+
+// chains lists the supported record types per chain, in the order they are
+// processed within a block.
+var chains = map[string][]struct {
+	typ  string
+	spec tableSpec
+}{
+	"ethereum": evmTypes,
+	"base":     evmTypes,
+	"beacon": {
+		{TypeBlock, tableSpec{"blocks", "slot_number", []string{"slot_number"}}},
+		{TypeWithdrawal, tableSpec{"withdrawals", "slot_number", []string{"slot_number", "index"}}},
+	},
+}
+
+var evmTypes = []struct {
+	typ  string
+	spec tableSpec
+}{
+	{TypeBlock, tableSpec{"blocks", "number", []string{"number"}}},
+	{TypeTransaction, tableSpec{"transactions", "block_number", []string{"hash"}}},
+	{TypeLog, tableSpec{"logs", "block_number", []string{"transaction_hash", "log_index"}}},
+}
+
+func lookup(chain, typ string) (tableSpec, int, error) {
+	types, ok := chains[chain]
+	if !ok {
+		return tableSpec{}, 0, fmt.Errorf("unsupported chain %q", chain)
+	}
+	for i, t := range types {
+		if t.typ == typ {
+			return t.spec, i, nil
+		}
+	}
+	return tableSpec{}, 0, fmt.Errorf("unsupported %s record type %q", chain, typ)
+}
+
+// Table returns the source table the record is loaded into.
+func (r *Record) Table() string {
+	spec, _, _ := lookup(r.Chain, r.Type)
+	return spec.table
+}
+
+// Row decodes Data, keeping numbers as json.Number.
+func (r *Record) Row() (map[string]any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(r.Data))
+	decoder.UseNumber()
+	row := map[string]any{}
+	if err := decoder.Decode(&row); err != nil {
+		return nil, err
+	}
+	return row, nil
+}
+
+// Key identifies the record among all records of a fixture set.
+func (r *Record) Key() string {
+	return strings.Join(append([]string{r.Chain, r.Type}, r.keyValues()...), "/")
+}
+
+func (r *Record) keyValues() []string {
+	spec, _, err := lookup(r.Chain, r.Type)
+	if err != nil {
+		return nil
+	}
+	row, err := r.Row()
+	if err != nil {
+		return nil
+	}
+	values := make([]string, len(spec.key))
+	for i, column := range spec.key {
+		values[i] = fmt.Sprint(row[column])
+	}
+	return values
+}
+
+// Validate checks that the record is of a known chain and type and that its
+// data carries the key columns of the type, matching Block.
+func (r *Record) Validate() error {
+	spec, _, err := lookup(r.Chain, r.Type)
+	if err != nil {
+		return err
+	}
+	if r.Block < 0 {
+		return fmt.Errorf("invalid block %d", r.Block)
+	}
+	if len(r.Data) == 0 {
+		return errors.New("data should not be empty")
+	}
+	row, err := r.Row()
+	if err != nil {
+		return fmt.Errorf("data should be a JSON object: %w", err)
+	}
+	for _, column := range spec.key {
+		if _, ok := row[column]; !ok {
+			return fmt.Errorf("%s data is missing column %q", r.Type, column)
+		}
+	}
+	number, ok := row[spec.block].(json.Number)
+	if !ok {
+		return fmt.Errorf("%s data is missing numeric column %q", r.Type, spec.block)
+	}
+	if number.String() != fmt.Sprint(r.Block) {
+		return fmt.Errorf("block %d does not match %s %s", r.Block, spec.block, number)
+	}
+	return nil
+}
+
+// Less orders records by block, then by type in processing order, then by
+// key. Numeric key columns, such as log_index, compare as numbers.
+func Less(a, b *Record) bool {
+	return newSortKey(a).less(newSortKey(b))
+}
+
+// sortKey is what records are ordered by, decoded from their data once.
+type sortKey struct {
+	block int64
+	index int
+	key   []string
+}
+
+func newSortKey(r *Record) sortKey {
+	_, index, _ := lookup(r.Chain, r.Type)
+	return sortKey{r.Block, index, r.keyValues()}
+}
+
+func (a sortKey) less(b sortKey) bool {
+	if a.block != b.block {
+		return a.block < b.block
+	}
+	if a.index != b.index {
+		return a.index < b.index
+	}
+	for i := 0; i < len(a.key) && i < len(b.key); i++ {
+		if a.key[i] == b.key[i] {
+			continue
+		}
+		an, aerr := strconv.ParseInt(a.key[i], 10, 64)
+		bn, berr := strconv.ParseInt(b.key[i], 10, 64)
+		if aerr == nil && berr == nil {
+			return an < bn
+		}
+		return a.key[i] < b.key[i]
+	}
+	return len(a.key) < len(b.key)
+}
+
+// Sort sorts records in processing order.
+func Sort(records []Record) {
+	type keyed struct {
+		key    sortKey
+		record Record
+	}
+	sorted := make([]keyed, len(records))
+	for i := range records {
+		sorted[i] = keyed{newSortKey(&records[i]), records[i]}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].key.less(sorted[j].key)
+	})
+	for i := range sorted {
+		records[i] = sorted[i].record
+	}
+}
+
+// Paths expands folders into the fixture files they contain.
+func Paths(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		for _, pattern := range []string{"*.ndjson", "*.ndjson.gz"} {
+			matches, err := filepath.Glob(filepath.Join(path, pattern))
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, matches...)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Problem is an invalid line of a fixture file.
+type Problem struct {
+	File string
+	Line int
+	Err  error
+}
+
+func (p Problem) Error() string {
+	return fmt.Sprintf("%s:%d: %s", p.File, p.Line, p.Err)
+}
+
+// Read reads every record of a fixture file, plain or gzip compressed. A
+// malformed or invalid line fails the read.
+func Read(path string) ([]Record, error) {
+	var records []Record
+	err := scan(path, func(line int, r Record, err error) error {
+		if err == nil {
+			err = r.Validate()
+		}
+		if err != nil {
+			return Problem{path, line, err}
+		}
+		records = append(records, r)
+		return nil
+	})
+	return records, err
+}
+
+// ReadAll reads the records of every fixture file under paths, in processing
+// order.
+func ReadAll(paths []string) ([]Record, error) {
+	files, err := Paths(paths)
+	if err != nil {
+		return nil, err
+	}
+	var records []Record
+	for _, file := range files {
+		r, err := Read(file)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, r...)
+	}
+	Sort(records)
+	return records, nil
+}
+
+// Validate reports every invalid line of a fixture file, and records that
+// appear more than once.
+func Validate(path string) ([]Problem, error) {
+	var problems []Problem
+	seen := make(map[string]int)
+	err := scan(path, func(line int, r Record, err error) error {
+		if err == nil {
+			err = r.Validate()
+		}
+		if err != nil {
+			problems = append(problems, Problem{path, line, err})
+			return nil
+		}
+		key := r.Key()
+		if first, ok := seen[key]; ok {
+			problems = append(problems, Problem{path, line, fmt.Errorf("duplicate of line %d", first)})
+			return nil
+		}
+		seen[key] = line
+		return nil
+	})
+	return problems, err
+}
+
+func scan(path string, fn func(line int, r Record, err error) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	reader, err := decompress(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(nil, 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		r := Record{}
+		err = json.Unmarshal(scanner.Bytes(), &r)
+		if err = fn(line, r, err); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// decompress returns a gzip reader if r starts with the gzip magic number.
+func decompress(r io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(r)
+	magic, err := buffered.Peek(2)
+	if err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		return gzip.NewReader(buffered)
+	}
+	return buffered, nil
+}
+
+// Write writes records to path, gzip compressed if path ends with .gz.
+func Write(path string, records []Record) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var w io.Writer = f
+	var gz *gzip.Writer
+	if strings.HasSuffix(path, ".gz") {
+		gz = gzip.NewWriter(f)
+		w = gz
+	}
+
+	buffered := bufio.NewWriter(w)
+	encoder := json.NewEncoder(buffered)
+	for i := range records {
+		if err = encoder.Encode(&records[i]); err != nil {
+			return err
+		}
+	}
+	if err = buffered.Flush(); err != nil {
+		return err
+	}
+	if gz != nil {
+		if err = gz.Close(); err != nil {
+			return err
+		}
+	}
+	return f.Close()
+}
+
+// Slice returns the records whose block is within [from, to].
+func Slice(records []Record, from, to int64) []Record {
+	var sliced []Record
+	for _, r := range records {
+		if r.Block >= from && r.Block <= to {
+			sliced = append(sliced, r)
+		}
+	}
+	return sliced
+}
+
+// Merge combines record sets in processing order. Identical duplicates are
+// dropped. A record that differs from an earlier one with the same key is an
+// error, unless overwrite is set, in which case the later one wins.
+func Merge(overwrite bool, sets ...[]Record) ([]Record, error) {
+	index := make(map[string]int)
+	var merged []Record
+	for _, set := range sets {
+		for _, r := range set {
+			key := r.Key()
+			i, ok := index[key]
+			if !ok {
+				index[key] = len(merged)
+				merged = append(merged, r)
+				continue
+			}
+			if bytes.Equal(compact(merged[i].Data), compact(r.Data)) {
+				continue
+			}
+			if !overwrite {
+				return nil, fmt.Errorf("conflicting records for %s", key)
+			}
+			merged[i] = r
+		}
+	}
+	Sort(merged)
+	return merged, nil
+}
+
+func compact(data json.RawMessage) []byte {
+	buf := &bytes.Buffer{}
+	if err := json.Compact(buf, data); err != nil {
+		return data
+	}
+	return buf.Bytes()
+}
+
+// Blocks returns the distinct block numbers of records, in order.
+func Blocks(records []Record) []int64 {
+	var blocks []int64
+	for _, r := range records {
+		if len(blocks) == 0 || blocks[len(blocks)-1] != r.Block {
+			blocks = append(blocks, r.Block)
+		}
+	}
+	return blocks
+}
+
+// Replace returns records where the blocks covered by fork, from its first
+// to its last block, are replaced by the records of fork.
+func Replace(records, fork []Record) []Record {
+	if len(fork) == 0 {
+		return records
+	}
+	from, to := fork[0].Block, fork[0].Block
+	for _, r := range fork {
+		from, to = min(from, r.Block), max(to, r.Block)
+	}
+
+	var replaced []Record
+	for _, r := range records {
+		if r.Block < from || r.Block > to {
+			replaced = append(replaced, r)
+		}
+	}
+	replaced = append(replaced, fork...)
+	Sort(replaced)
+	return replaced
+}
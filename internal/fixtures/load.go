@@ -0,0 +1,29 @@
+package fixtures
+
+import (
+	"database/sql"
+
+	"github.com/Zettablock/zetta-go/internal/localdb"
+)
+
+// Load inserts records into their source tables under schema, creating the
+// tables as needed. It returns the number of rows loaded per table.
+func Load(db *sql.DB, schema string, records []Record) (map[string]int, error) {
+	tables := make(map[string][]localdb.Row)
+	for _, r := range records {
+		row, err := r.Row()
+		if err != nil {
+			return nil, err
+		}
+		tables[r.Table()] = append(tables[r.Table()], row)
+	}
+
+	counts := make(map[string]int)
+	for table, rows := range tables {
+		if err := localdb.LoadTable(db, schema, table, rows); err != nil {
+			return nil, err
+		}
+		counts[table] = len(rows)
+	}
+	return counts, nil
+}
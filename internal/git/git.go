@@ -0,0 +1,164 @@
+// Package git reads the state of the git repository a project is in, to pin
+// deployments to a commit the hosted service can fetch.
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"os/user"
+	"strings"
+)
+
+// ErrNotRepository is returned for a folder outside of any git repository.
+var ErrNotRepository = errors.New("not in a git repository")
+
+// ErrUnknownCommit is returned for a commit the repository does not have.
+var ErrUnknownCommit = errors.New("unknown commit")
+
+// Head is the commit checked out, and its branch, empty if detached.
+type Head struct {
+	Commit string
+	Branch string
+}
+
+// ReadHead returns the commit checked out in the repository of dir.
+func ReadHead(dir string) (Head, error) {
+	commit, err := run(dir, "rev-parse", "--verify", "HEAD")
+	if err != nil {
+		return Head{}, err
+	}
+	branch, err := run(dir, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return Head{}, err
+	}
+	if branch == "HEAD" {
+		branch = ""
+	}
+	return Head{Commit: commit, Branch: branch}, nil
+}
+
+// Resolve returns the commit a branch, tag or commit SHA refers to.
+func Resolve(dir, ref string) (string, error) {
+	commit, err := run(dir, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("%s is not a branch, tag or commit of the repository", ref)
+	}
+	return commit, nil
+}
+
+// Changes returns the files of dir, tracked or not, that differ from the
+// commit checked out, as git status --porcelain lists them. The exclude paths
+// of dir, such as local state, are left out.
+func Changes(dir string, exclude ...string) ([]string, error) {
+	args := []string{"status", "--porcelain", "--", "."}
+	for _, path := range exclude {
+		args = append(args, ":(exclude)"+path)
+	}
+	out, err := run(dir, args...)
+	if err != nil || out == "" {
+		return nil, err
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+// Pushed reports whether a commit is on a branch of a remote, as last
+// fetched.
+func Pushed(dir, commit string) (bool, error) {
+	out, err := run(dir, "branch", "--remotes", "--contains", commit)
+	if err != nil {
+		return false, err
+	}
+	return out != "", nil
+}
+
+// Diff returns the files of dir that changed since a commit, committed or
+// not, relative to dir. Untracked files are not listed.
+func Diff(dir, commit string) ([]string, error) {
+	if _, err := run(dir, "cat-file", "-e", commit+"^{commit}"); err != nil {
+		if err == ErrNotRepository {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w %s, fetch it", ErrUnknownCommit, commit)
+	}
+	out, err := run(dir, "diff", "--name-only", "--relative", commit, "--", ".")
+	if err != nil || out == "" {
+		return nil, err
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+// User identifies who runs the command, for the service to record: the
+// email of the git user, as configured for dir, or else the system user.
+func User(dir string) string {
+	if email, _ := run(dir, "config", "user.email"); email != "" {
+		return email
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return "unknown"
+}
+
+func run(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if strings.Contains(stderr.String(), "not a git repository") {
+			return "", ErrNotRepository
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Commit commits the given files of dir, and only them, and returns the new
+// commit.
+func Commit(dir, message string, files ...string) (string, error) {
+	args := append([]string{"commit", "--quiet", "--message", message, "--"}, files...)
+	if _, err := run(dir, args...); err != nil {
+		return "", err
+	}
+	return run(dir, "rev-parse", "--verify", "HEAD")
+}
+
+// Tag creates an annotated tag on a commit.
+func Tag(dir, name, commit string) error {
+	_, err := run(dir, "tag", "--annotate", "--message", name, name, commit)
+	return err
+}
+
+// Push pushes the given tags, and the branch checked out if head is set,
+// to the remote of the upstream branch, or else origin.
+func Push(dir string, head bool, tags ...string) error {
+	remote, branch := "origin", ""
+	upstream, err := run(dir, "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}")
+	if err == nil {
+		var ok bool
+		if remote, branch, ok = strings.Cut(upstream, "/"); !ok {
+			return fmt.Errorf("unexpected upstream branch %s", upstream)
+		}
+	} else if head {
+		return fmt.Errorf("the branch has no upstream branch to push to: %w", err)
+	}
+
+	args := []string{"push", "--quiet", remote}
+	if head {
+		args = append(args, "HEAD:refs/heads/"+branch)
+	}
+	for _, tag := range tags {
+		args = append(args, "refs/tags/"+tag)
+	}
+	if len(args) == 3 {
+		return nil
+	}
+	_, err = run(dir, args...)
+	return err
+}
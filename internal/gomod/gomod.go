@@ -0,0 +1,226 @@
+// Package gomod finds which zsource a project builds against, taking replace
+// directives, pseudo-versions and go.work workspaces into account.
+package gomod
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+const (
+	// ZSourceModule is the module path of zsource.
+	ZSourceModule = "github.com/Zettablock/zsource"
+
+	goModFile  = "go.mod"
+	goWorkFile = "go.work"
+)
+
+// ErrNoZSource is returned when go.mod does not require zsource.
+var ErrNoZSource = errors.New("zsource module not found in go.mod file")
+
+// Requirement is the zsource a project builds against.
+type Requirement struct {
+	// Version is the version go.mod requires, e.g. v0.2.0 or a
+	// pseudo-version.
+	Version string
+	// Commit is the revision of a pseudo-version.
+	Commit string
+	// Base is the release Version derives from: Version itself, or the
+	// release preceding a pseudo-version, v0.0.0 if there is none.
+	Base string
+	// Replace is set if a replace directive, or a go.work workspace, swaps
+	// zsource for another module or a local folder.
+	Replace *Replacement
+}
+
+// Replacement is what zsource is replaced with.
+type Replacement struct {
+	// Path is a module path, or a local folder if Version is empty.
+	Path    string
+	Version string
+	// File is the go.mod or go.work file that replaces zsource.
+	File string
+}
+
+// Local reports whether zsource is replaced with a local folder.
+func (r *Replacement) Local() bool {
+	return r.Version == ""
+}
+
+func (r *Replacement) String() string {
+	if r.Local() {
+		return fmt.Sprintf("the local folder %s (%s)", r.Path, r.File)
+	}
+	return fmt.Sprintf("%s %s (%s)", r.Path, r.Version, r.File)
+}
+
+// LocalError is a zsource replaced with a local folder, which only exists on
+// this machine.
+type LocalError struct {
+	Replace *Replacement
+}
+
+func (e *LocalError) Error() string {
+	hint := "remove the replace directive"
+	if filepath.Base(e.Replace.File) == goWorkFile {
+		hint = "set GOWORK=off"
+	}
+	return fmt.Sprintf("zsource is replaced with %s, which the hosted service cannot build; %s", e.Replace, hint)
+}
+
+// ZSource reads the zsource requirement of the module in dir.
+func ZSource(dir string) (*Requirement, error) {
+	path := filepath.Join(dir, goModFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := modfile.Parse(path, data, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &Requirement{}
+	for _, r := range f.Require {
+		if r.Mod.Path == ZSourceModule {
+			req.Version = r.Mod.Version
+			break
+		}
+	}
+	if req.Version == "" {
+		return nil, ErrNoZSource
+	}
+
+	req.Base = req.Version
+	if module.IsPseudoVersion(req.Version) {
+		if req.Commit, err = module.PseudoVersionRev(req.Version); err != nil {
+			return nil, err
+		}
+		if req.Base, err = module.PseudoVersionBase(req.Version); err != nil {
+			return nil, err
+		}
+		if req.Base == "" {
+			req.Base = "v0.0.0"
+		}
+	}
+
+	req.Replace = replacement(f.Replace, req.Version, path)
+
+	// a workspace takes precedence over go.mod
+	work, err := Workspace(dir)
+	if err != nil || work == "" {
+		return req, err
+	}
+	r, err := workspaceReplacement(work, req.Version)
+	if err != nil {
+		return nil, err
+	}
+	if r != nil {
+		req.Replace = r
+	}
+	return req, nil
+}
+
+// Requires reports whether the go.mod of the module in dir requires the
+// module at path, directly or not.
+func Requires(dir, path string) (bool, error) {
+	file := filepath.Join(dir, goModFile)
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return false, err
+	}
+	f, err := modfile.Parse(file, data, nil)
+	if err != nil {
+		return false, err
+	}
+	for _, r := range f.Require {
+		if r.Mod.Path == path {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Strict returns a LocalError if zsource is replaced with a local folder.
+func (r *Requirement) Strict() error {
+	if r.Replace != nil && r.Replace.Local() {
+		return &LocalError{Replace: r.Replace}
+	}
+	return nil
+}
+
+func replacement(replaces []*modfile.Replace, version, file string) *Replacement {
+	for _, r := range replaces {
+		if r.Old.Path != ZSourceModule || (r.Old.Version != "" && r.Old.Version != version) {
+			continue
+		}
+		path := r.New.Path
+		if r.New.Version == "" && !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(file), path)
+		}
+		return &Replacement{Path: path, Version: r.New.Version, File: file}
+	}
+	return nil
+}
+
+// workspaceReplacement returns how the workspace replaces zsource: with a
+// module it uses, or with a replace directive.
+func workspaceReplacement(work, version string) (*Replacement, error) {
+	data, err := os.ReadFile(work)
+	if err != nil {
+		return nil, err
+	}
+	f, err := modfile.ParseWork(work, data, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, use := range f.Use {
+		dir := use.Path
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(filepath.Dir(work), dir)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, goModFile))
+		if err != nil {
+			continue
+		}
+		if modfile.ModulePath(data) == ZSourceModule {
+			return &Replacement{Path: dir, File: work}, nil
+		}
+	}
+	return replacement(f.Replace, version, work), nil
+}
+
+// Workspace returns the go.work file that applies to the module in dir, or
+// "" if it builds on its own: $GOWORK, unless it is off, or else the nearest
+// go.work in dir or its parents.
+func Workspace(dir string) (string, error) {
+	switch env := os.Getenv("GOWORK"); env {
+	case "off":
+		return "", nil
+	case "":
+	default:
+		return env, nil
+	}
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		path := filepath.Join(dir, goWorkFile)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
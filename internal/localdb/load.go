@@ -0,0 +1,178 @@
+package localdb
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Row is a source table row keyed by column name.
+type Row map[string]any
+
+// LoadTable creates table under schema, if needed, and inserts rows into it.
+// Column types are inferred from the values of the rows: JSON numbers become
+// bigint or numeric, RFC 3339 strings timestamptz, string arrays text[] and
+// any other object or array jsonb.
+func LoadTable(db *sql.DB, schema, table string, rows []Row) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	columns := columnTypes(rows)
+	names := make([]string, 0, len(columns))
+	for name := range columns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	defs := make([]string, len(names))
+	for i, name := range names {
+		defs[i] = fmt.Sprintf("%s %s", pq.QuoteIdentifier(name), columns[name])
+	}
+
+	qualified := fmt.Sprintf("%s.%s", pq.QuoteIdentifier(schema), pq.QuoteIdentifier(table))
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Exec(fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s", pq.QuoteIdentifier(schema))); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", qualified, strings.Join(defs, ", "))); err != nil {
+		return err
+	}
+	for _, name := range names {
+		stmt := fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s", qualified, pq.QuoteIdentifier(name), columns[name])
+		if _, err = tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = pq.QuoteIdentifier(name)
+	}
+	placeholders := make([]string, len(names))
+	for i := range names {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+	}
+	stmt, err := tx.Prepare(fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", qualified, strings.Join(quoted, ", "), strings.Join(placeholders, ", ")))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, row := range rows {
+		args := make([]any, len(names))
+		for i, name := range names {
+			if args[i], err = columnValue(row[name], columns[name]); err != nil {
+				return fmt.Errorf("%s.%s: %w", table, name, err)
+			}
+		}
+		if _, err = stmt.Exec(args...); err != nil {
+			return fmt.Errorf("%s: %w", table, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// columnTypes infers a postgres type for every column seen in rows. A column
+// whose values disagree falls back to text, or jsonb for composite values.
+func columnTypes(rows []Row) map[string]string {
+	types := make(map[string]string)
+	for _, row := range rows {
+		for name, value := range row {
+			t := valueType(value)
+			if t == "" {
+				continue
+			}
+			switch prev, ok := types[name]; {
+			case !ok || prev == t:
+				types[name] = t
+			case prev == "bigint" && t == "numeric", prev == "numeric" && t == "bigint":
+				types[name] = "numeric"
+			case prev == "jsonb" || t == "jsonb" || prev == "text[]" || t == "text[]":
+				types[name] = "jsonb"
+			default:
+				types[name] = "text"
+			}
+		}
+	}
+	for _, row := range rows {
+		for name := range row {
+			if _, ok := types[name]; !ok {
+				types[name] = "text"
+			}
+		}
+	}
+	return types
+}
+
+func valueType(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case bool:
+		return "boolean"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "bigint"
+		}
+		return "numeric"
+	case float64:
+		if v == float64(int64(v)) {
+			return "bigint"
+		}
+		return "numeric"
+	case string:
+		if _, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return "timestamptz"
+		}
+		return "text"
+	case []any:
+		for _, item := range v {
+			if _, ok := item.(string); !ok {
+				return "jsonb"
+			}
+		}
+		return "text[]"
+	default:
+		return "jsonb"
+	}
+}
+
+func columnValue(value any, columnType string) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+	switch columnType {
+	case "text[]":
+		items := value.([]any)
+		array := make(pq.StringArray, len(items))
+		for i, item := range items {
+			array[i] = item.(string)
+		}
+		return array, nil
+	case "jsonb":
+		return json.Marshal(value)
+	case "text":
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+		return fmt.Sprint(value), nil
+	default:
+		if n, ok := value.(json.Number); ok {
+			return n.String(), nil
+		}
+		return value, nil
+	}
+}
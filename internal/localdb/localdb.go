@@ -0,0 +1,178 @@
+// Package localdb manages the embedded Postgres instance used to run zrunner
+// pipelines locally, without any external database.
+package localdb
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
+	_ "github.com/lib/pq"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// StateDir is the per-project directory holding local zrunner state.
+	StateDir = ".zrunner"
+
+	DefaultPort     = 5433 // avoid clashing with a system postgres on 5432
+	DefaultUsername = "zrunner"
+	DefaultPassword = "zrunner"
+	DefaultDatabase = "zrunner"
+
+	postgresDir  = "postgres"
+	instanceFile = "db.yml"
+)
+
+// ErrNotRunning is returned by Lookup when no local database has been started
+// for the project.
+var ErrNotRunning = errors.New("local database is not running, start it with `zrunner dev db up`")
+
+// Instance describes a local database started for a project. It is persisted
+// under .zrunner/ so other commands can connect to it.
+type Instance struct {
+	Port              uint32 `yaml:"port"`
+	Username          string `yaml:"username"`
+	Password          string `yaml:"password"`
+	Database          string `yaml:"database"`
+	SourceSchema      string `yaml:"sourceSchema"`
+	DestinationSchema string `yaml:"destinationSchema"`
+}
+
+// DSN returns a connection string whose search_path is the given schema.
+func (i *Instance) DSN(schema string) string {
+	u := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(i.Username, i.Password),
+		Host:   fmt.Sprintf("localhost:%d", i.Port),
+		Path:   i.Database,
+	}
+	q := url.Values{}
+	q.Set("sslmode", "disable")
+	if schema != "" {
+		q.Set("search_path", schema)
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// SourceDSN is the connection string handlers get as deps.SourceDB.
+func (i *Instance) SourceDSN() string {
+	return i.DSN(i.SourceSchema)
+}
+
+// DestinationDSN is the connection string handlers get as deps.DestinationDB.
+func (i *Instance) DestinationDSN() string {
+	return i.DSN(i.DestinationSchema)
+}
+
+// Open connects to the instance without a default schema.
+func (i *Instance) Open() (*sql.DB, error) {
+	db, err := sql.Open("postgres", i.DSN(""))
+	if err != nil {
+		return nil, err
+	}
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+func paths(root string) (runtime, binaries, data string) {
+	base := filepath.Join(root, StateDir, postgresDir)
+	return filepath.Join(base, "runtime"), filepath.Join(base, "binaries"), filepath.Join(base, "data")
+}
+
+// Up starts the embedded Postgres for the project at root and records the
+// instance so that Lookup can find it. The server keeps running after Up
+// returns, until Down is called.
+func Up(root string, inst *Instance, logger io.Writer) error {
+	runtime, binaries, data := paths(root)
+
+	if existing, err := Lookup(root); err == nil {
+		if existing.Port != inst.Port {
+			return fmt.Errorf("local database is already running on port %d", existing.Port)
+		}
+		return save(root, inst)
+	}
+
+	cfg := embeddedpostgres.DefaultConfig().
+		Port(inst.Port).
+		Username(inst.Username).
+		Password(inst.Password).
+		Database(inst.Database).
+		RuntimePath(runtime).
+		BinariesPath(binaries).
+		DataPath(data).
+		Logger(logger)
+
+	if err := embeddedpostgres.NewDatabase(cfg).Start(); err != nil {
+		return err
+	}
+
+	return save(root, inst)
+}
+
+// Down stops the local database of the project at root. Data is kept under
+// .zrunner/postgres/data unless purge is set.
+func Down(root string, purge bool) error {
+	_, binaries, data := paths(root)
+
+	if _, err := os.Stat(filepath.Join(root, StateDir, instanceFile)); err == nil {
+		stop := exec.Command(filepath.Join(binaries, "bin", "pg_ctl"), "stop", "-w", "-D", data)
+		if out, err := stop.CombinedOutput(); err != nil {
+			return fmt.Errorf("could not stop postgres: %s", string(out))
+		}
+		if err = os.Remove(filepath.Join(root, StateDir, instanceFile)); err != nil {
+			return err
+		}
+	}
+
+	if purge {
+		return os.RemoveAll(filepath.Join(root, StateDir, postgresDir))
+	}
+	return nil
+}
+
+// Lookup returns the running local database of the project at root, or
+// ErrNotRunning.
+func Lookup(root string) (*Instance, error) {
+	data, err := os.ReadFile(filepath.Join(root, StateDir, instanceFile))
+	if os.IsNotExist(err) {
+		return nil, ErrNotRunning
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	inst := &Instance{}
+	if err = yaml.Unmarshal(data, inst); err != nil {
+		return nil, err
+	}
+
+	db, err := inst.Open()
+	if err != nil {
+		return nil, ErrNotRunning
+	}
+	db.Close()
+
+	return inst, nil
+}
+
+func save(root string, inst *Instance) error {
+	data, err := yaml.Marshal(inst)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Join(root, StateDir), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(root, StateDir, instanceFile), data, 0644)
+}
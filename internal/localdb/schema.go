@@ -0,0 +1,95 @@
+package localdb
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/lib/pq"
+)
+
+// SchemaExists reports whether the given schema has been created.
+func SchemaExists(db *sql.DB, schema string) (bool, error) {
+	var exists bool
+	err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM information_schema.schemata WHERE schema_name = $1)", schema).Scan(&exists)
+	return exists, err
+}
+
+// ResetSchema drops the schema if it exists and creates it again, empty.
+func ResetSchema(db *sql.DB, schema string) error {
+	_, err := db.Exec(fmt.Sprintf("DROP SCHEMA IF EXISTS %s CASCADE; CREATE SCHEMA %s", pq.QuoteIdentifier(schema), pq.QuoteIdentifier(schema)))
+	return err
+}
+
+// ApplySchemas runs every .sql file of dir, in name order, with schema as the
+// search_path so that unqualified tables are created under it.
+func ApplySchemas(db *sql.DB, schema, dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Exec(fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s", pq.QuoteIdentifier(schema))); err != nil {
+		return nil, err
+	}
+	if _, err = tx.Exec(fmt.Sprintf("SET LOCAL search_path TO %s", pq.QuoteIdentifier(schema))); err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		script, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = tx.Exec(string(script)); err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(file), err)
+		}
+	}
+
+	return files, tx.Commit()
+}
+
+// DeleteFromBlock deletes, from every table of schema having the given block
+// column, the rows at or after block from. It returns the rows deleted per
+// table.
+func DeleteFromBlock(db *sql.DB, schema, column string, from int64) (map[string]int64, error) {
+	rows, err := db.Query("SELECT table_name FROM information_schema.columns WHERE table_schema = $1 AND column_name = $2 ORDER BY table_name", schema, column)
+	if err != nil {
+		return nil, err
+	}
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err = rows.Scan(&table); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	deleted := make(map[string]int64)
+	for _, table := range tables {
+		stmt := fmt.Sprintf("DELETE FROM %s.%s WHERE %s >= $1", pq.QuoteIdentifier(schema), pq.QuoteIdentifier(table), pq.QuoteIdentifier(column))
+		res, err := db.Exec(stmt, from)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", table, err)
+		}
+		if deleted[table], err = res.RowsAffected(); err != nil {
+			return nil, err
+		}
+	}
+	return deleted, nil
+}
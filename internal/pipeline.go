@@ -2,9 +2,13 @@ package internal
 
 import (
 	_ "embed"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
+
+	"gopkg.in/yaml.v3"
 )
 
 type Pipeline struct {
@@ -55,5 +59,106 @@ func (p *Pipeline) Create() error {
 		return err
 	}
 
+	// create handler tests
+	cfg, err := readPipelineConfig(configFileName)
+	if err != nil {
+		return err
+	}
+	_, err = GenerateTests(p.WorkingDir, cfg, false)
+	return err
+}
+
+// RenamePipeline moves the folder of a pipeline of the project next to it,
+// under the new name, and renames it in pipeline.yml and in the pipelines
+// list of project.yml. It returns the new folder, relative to the project.
+func RenamePipeline(project *ProjectConfig, pipeline PipelineConfig, name string) (string, error) {
+	if _, err := project.Pipeline(name); err == nil {
+		return "", fmt.Errorf("pipeline %s already exists", name)
+	}
+	dir := filepath.Join(filepath.Dir(pipeline.Dir), name)
+	from := filepath.Join(project.Root, pipeline.Dir)
+	to := filepath.Join(project.Root, dir)
+	if _, err := os.Stat(to); err == nil {
+		return "", fmt.Errorf("%s already exists", dir)
+	}
+
+	// move the folder first, so that a failed move leaves the pipeline as it
+	// was, and move it back if the configuration cannot be edited
+	if err := os.Rename(from, to); err != nil {
+		return "", err
+	}
+	if err := renameConfig(project, pipeline, dir, name); err != nil {
+		return "", errors.Join(err, os.Rename(to, from))
+	}
+	return dir, nil
+}
+
+// renameConfig renames a pipeline moved to dir in its pipeline.yml, then in
+// project.yml. pipeline.yml is restored if project.yml cannot be edited.
+func renameConfig(project *ProjectConfig, pipeline PipelineConfig, dir, name string) error {
+	configFile := filepath.Join(project.Root, dir, pipelineYml)
+	original, err := os.ReadFile(configFile)
+	if err != nil {
+		return err
+	}
+	err = editYAML(configFile, func(data []byte, doc *yaml.Node) ([]byte, error) {
+		node := mappingValue(doc, "name")
+		if node == nil {
+			return nil, fmt.Errorf("%s has no name", filepath.Join(pipeline.Dir, pipelineYml))
+		}
+		return replaceScalar(data, node, name), nil
+	})
+	if err != nil {
+		return err
+	}
+	if err = renameListed(project, pipeline.Dir, dir); err != nil {
+		return errors.Join(err, os.WriteFile(configFile, original, 0644))
+	}
 	return nil
 }
+
+// DeletePipeline removes the folder of a pipeline of the project, and its
+// entry in the pipelines list of project.yml.
+func DeletePipeline(project *ProjectConfig, pipeline PipelineConfig) error {
+	if err := renameListed(project, pipeline.Dir, ""); err != nil {
+		return err
+	}
+	return os.RemoveAll(filepath.Join(project.Root, pipeline.Dir))
+}
+
+// renameListed replaces the entry dir of the pipelines list of project.yml
+// with to, or removes it if to is empty. Globs are left as they are.
+func renameListed(project *ProjectConfig, dir, to string) error {
+	dir = filepath.ToSlash(dir)
+	listed := false
+	for _, p := range project.PipelinePaths {
+		if strings.TrimSuffix(filepath.ToSlash(filepath.Clean(p)), "/") == dir {
+			listed = true
+		}
+	}
+	if !listed {
+		return nil
+	}
+
+	return editYAML(filepath.Join(project.Root, projectYml), func(data []byte, doc *yaml.Node) ([]byte, error) {
+		list := mappingValue(doc, "pipelines")
+		if list == nil || list.Kind != yaml.SequenceNode {
+			return data, nil
+		}
+		// edit from the end, so that the positions of the other entries hold
+		for i := len(list.Content) - 1; i >= 0; i-- {
+			node := list.Content[i]
+			if strings.TrimSuffix(filepath.ToSlash(filepath.Clean(node.Value)), "/") != dir {
+				continue
+			}
+			if to != "" {
+				data = replaceScalar(data, node, filepath.ToSlash(to))
+			} else if list.Style&yaml.FlowStyle == 0 {
+				data = removeLine(data, node.Line)
+			} else {
+				return nil, fmt.Errorf("remove %s from the pipelines list of %s", dir, projectYml)
+			}
+		}
+		return data, nil
+	})
+}
@@ -6,6 +6,11 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/Zettablock/zetta-go/internal/compat"
+	"github.com/Zettablock/zetta-go/internal/localdb"
+
+	"gopkg.in/yaml.v3"
 )
 
 const (
@@ -17,6 +22,7 @@ const (
 	examplePipelineDirName = "example-pipeline"
 	schemasDir             = "schemas"
 	exampleSchemaFile      = "example.sql"
+	gitignoreFile          = ".gitignore"
 )
 
 //go:embed templates/project.yml.tmpl
@@ -37,8 +43,17 @@ var goModTemplate string
 //go:embed templates/example.sql.tmpl
 var exampleSchemaTemplate string
 
+// RunnerTemplate is the program that loads pipeline plugins and invokes
+// their handlers for local runs.
+//
+//go:embed templates/runner.go.tmpl
+var RunnerTemplate string
+
 type Project struct {
 	WorkingDir string
+	// ZSourceVersion is the zsource version go.mod requires, by default the
+	// recommended version of the newest entry of the compatibility matrix.
+	ZSourceVersion string
 }
 
 func (p *Project) Create() error {
@@ -112,11 +127,73 @@ func (p *Project) Create() error {
 	}
 
 	// create go.mod
+	zsourceVersion := p.ZSourceVersion
+	if zsourceVersion == "" {
+		zsourceVersion = compat.Embedded()[0].Recommended
+	}
+	goMod := strings.Replace(goModTemplate, "[zsource-version]", zsourceVersion, -1)
 	goModFileName := fmt.Sprintf("%s/%s", p.WorkingDir, goModFile)
-	err = os.WriteFile(goModFileName, []byte(goModTemplate), mode)
+	err = os.WriteFile(goModFileName, []byte(goMod), mode)
 	if err != nil {
 		return err
 	}
 
+	// keep the local secrets and run state out of the repository
+	if err = ignoreLocalFiles(p.WorkingDir); err != nil {
+		return err
+	}
+
+	// create handler tests
+	cfg, err := readPipelineConfig(configFileName)
+	if err != nil {
+		return err
+	}
+	_, err = GenerateTests(p.WorkingDir, cfg, false)
+	return err
+}
+
+// ignoreLocalFiles adds .env and .zrunner/ to the .gitignore of dir, unless
+// they are there.
+func ignoreLocalFiles(dir string) error {
+	path := filepath.Join(dir, gitignoreFile)
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	ignored := make(map[string]bool)
+	for _, line := range strings.Split(string(data), "\n") {
+		ignored[strings.TrimSuffix(strings.TrimSpace(line), "/")] = true
+	}
+	size := len(data)
+	for _, name := range []string{DotEnvFile, localdb.StateDir + "/"} {
+		if ignored[strings.TrimSuffix(name, "/")] {
+			continue
+		}
+		if len(data) > 0 && !strings.HasSuffix(string(data), "\n") {
+			data = append(data, '\n')
+		}
+		data = append(data, name+"\n"...)
+	}
+	if len(data) == size {
+		return nil
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// SetVersion rewrites the version of project.yml, keeping its comments and
+// formatting.
+func SetVersion(project *ProjectConfig, version string) error {
+	path := filepath.Join(project.Root, projectYml)
+	err := editYAML(path, func(data []byte, doc *yaml.Node) ([]byte, error) {
+		node := mappingValue(doc, "version")
+		if node == nil || node.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf("%s has no version", path)
+		}
+		return replaceScalar(data, node, version), nil
+	})
+	if err != nil {
+		return err
+	}
+	project.Version = version
 	return nil
 }
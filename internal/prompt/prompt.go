@@ -0,0 +1,23 @@
+// Package prompt asks the user for confirmation on the terminal.
+package prompt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Confirm asks a yes/no question on stdout and reads the answer from stdin.
+// Anything but yes is no.
+func Confirm(question string) bool {
+	return confirm(os.Stdin, os.Stdout, question)
+}
+
+func confirm(in io.Reader, out io.Writer, question string) bool {
+	fmt.Fprintf(out, "%s [y/N] ", question)
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,167 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"regexp"
+	"time"
+)
+
+const (
+	defaultMaxAttempts = 3
+	defaultBackoff     = time.Second
+	defaultMaxBackoff  = time.Minute
+	defaultMultiplier  = 2
+)
+
+// RetryConfig is the retry block of pipeline.yml. It defines how failed
+// handler invocations are retried, and is sent as is to the hosted service.
+type RetryConfig struct {
+	// MaxAttempts counts the first invocation, 3 by default.
+	MaxAttempts int `yaml:"maxAttempts" json:"max_attempts,omitempty"`
+	// Backoff is the delay before the first retry, 1s by default. It is
+	// multiplied by Multiplier, 2 by default, after every retry, up to
+	// MaxBackoff, 1m by default.
+	Backoff    string  `yaml:"backoff" json:"backoff,omitempty"`
+	MaxBackoff string  `yaml:"maxBackoff" json:"max_backoff,omitempty"`
+	Multiplier float64 `yaml:"multiplier" json:"multiplier,omitempty"`
+	// Jitter randomises every delay by up to this fraction of it.
+	Jitter float64 `yaml:"jitter" json:"jitter,omitempty"`
+	// Fatal errors, as regular expressions, are never retried, even if the
+	// handler asks to. Retryable errors are retried even if the handler does
+	// not ask to.
+	Fatal     []string `yaml:"fatal" json:"fatal,omitempty"`
+	Retryable []string `yaml:"retryable" json:"retryable,omitempty"`
+}
+
+// RetryPolicy is a validated RetryConfig.
+type RetryPolicy struct {
+	MaxAttempts int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	multiplier  float64
+	jitter      float64
+	fatal       []*regexp.Regexp
+	retryable   []*regexp.Regexp
+}
+
+// Policy validates the configuration and fills in the defaults.
+func (c *RetryConfig) Policy() (*RetryPolicy, error) {
+	p := &RetryPolicy{
+		MaxAttempts: c.MaxAttempts,
+		backoff:     defaultBackoff,
+		maxBackoff:  defaultMaxBackoff,
+		multiplier:  c.Multiplier,
+		jitter:      c.Jitter,
+	}
+
+	var err error
+	switch {
+	case c.MaxAttempts < 0:
+		return nil, errors.New("retry maxAttempts should not be negative")
+	case c.MaxAttempts == 0:
+		p.MaxAttempts = defaultMaxAttempts
+	}
+	if c.Backoff != "" {
+		if p.backoff, err = time.ParseDuration(c.Backoff); err != nil || p.backoff < 0 {
+			return nil, fmt.Errorf("invalid retry backoff %q", c.Backoff)
+		}
+	}
+	if c.MaxBackoff != "" {
+		if p.maxBackoff, err = time.ParseDuration(c.MaxBackoff); err != nil || p.maxBackoff < 0 {
+			return nil, fmt.Errorf("invalid retry maxBackoff %q", c.MaxBackoff)
+		}
+	}
+	if p.maxBackoff < p.backoff {
+		return nil, fmt.Errorf("retry backoff %s should not exceed maxBackoff %s", p.backoff, p.maxBackoff)
+	}
+	switch {
+	case c.Multiplier == 0:
+		p.multiplier = defaultMultiplier
+	case c.Multiplier < 1:
+		return nil, errors.New("retry multiplier should be at least 1")
+	}
+	if c.Jitter < 0 || c.Jitter > 1 {
+		return nil, errors.New("retry jitter should be between 0 and 1")
+	}
+	if p.fatal, err = compilePatterns(c.Fatal); err != nil {
+		return nil, fmt.Errorf("invalid retry fatal pattern: %w", err)
+	}
+	if p.retryable, err = compilePatterns(c.Retryable); err != nil {
+		return nil, fmt.Errorf("invalid retry retryable pattern: %w", err)
+	}
+	return p, nil
+}
+
+// RetryPolicy returns the retry policy of the pipeline, the default one if
+// it has no retry block.
+func (p PipelineConfig) RetryPolicy() (*RetryPolicy, error) {
+	if p.Retry == nil {
+		return (&RetryConfig{}).Policy()
+	}
+	return p.Retry.Policy()
+}
+
+// Config returns the configuration of the policy, defaults included.
+func (p *RetryPolicy) Config() RetryConfig {
+	c := RetryConfig{
+		MaxAttempts: p.MaxAttempts,
+		Backoff:     p.backoff.String(),
+		MaxBackoff:  p.maxBackoff.String(),
+		Multiplier:  p.multiplier,
+		Jitter:      p.jitter,
+	}
+	for _, re := range p.fatal {
+		c.Fatal = append(c.Fatal, re.String())
+	}
+	for _, re := range p.retryable {
+		c.Retryable = append(c.Retryable, re.String())
+	}
+	return c
+}
+
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	var compiled []*regexp.Regexp
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// Retryable reports whether a failed invocation should be retried, given
+// its error and whether the handler asked to be retried.
+func (p *RetryPolicy) Retryable(err string, retry bool) bool {
+	for _, re := range p.fatal {
+		if re.MatchString(err) {
+			return false
+		}
+	}
+	if retry {
+		return true
+	}
+	for _, re := range p.retryable {
+		if re.MatchString(err) {
+			return true
+		}
+	}
+	return false
+}
+
+// Delay returns how long to wait after the given failed attempt, counting
+// from 1, before the next one.
+func (p *RetryPolicy) Delay(attempt int) time.Duration {
+	delay := float64(p.backoff)
+	for i := 1; i < attempt && delay < float64(p.maxBackoff); i++ {
+		delay *= p.multiplier
+	}
+	delay = min(delay, float64(p.maxBackoff))
+	if p.jitter > 0 {
+		delay += delay * p.jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(delay)
+}
@@ -0,0 +1,205 @@
+// Package runner executes zrunner pipelines locally, against fixture data and
+// the local database.
+//
+// Handlers live in package main of every pipeline folder and depend on the
+// zsource version pinned by the project, so they cannot be linked into the
+// CLI. Instead every pipeline is built as a Go plugin and a small runner
+// program, generated inside the project module, loads the plugins and invokes
+// handlers on request.
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/Zettablock/zetta-go/internal"
+	"github.com/Zettablock/zetta-go/internal/gomod"
+	"github.com/Zettablock/zetta-go/internal/localdb"
+)
+
+const (
+	buildDir     = "build"
+	runnerDir    = "runner"
+	runnerSource = "main.go"
+	runnerBinary = "runner"
+	// modFile is the copy of go.mod local builds resolve missing
+	// requirements into, and sumFile the copy of go.sum, named after it as
+	// -modfile requires.
+	modFile = "local.mod"
+	sumFile = "local.sum"
+)
+
+// BuildError is a failed compilation of a pipeline or of the runner.
+type BuildError struct {
+	Target string
+	Output string
+}
+
+func (e *BuildError) Error() string {
+	return fmt.Sprintf("%s does not build:\n%s", e.Target, strings.TrimSpace(e.Output))
+}
+
+// PluginPath is where the plugin of a pipeline is built.
+func PluginPath(root, pipeline string) string {
+	return filepath.Join(root, localdb.StateDir, buildDir, pipeline+".so")
+}
+
+// Build is the result of a local build: the plugin of every pipeline, by
+// name, and the runner program.
+type Build struct {
+	Plugins map[string]string
+	Runner  string
+}
+
+// BuildLocal compiles the pipelines of the project at root as Go plugins and
+// the runner program. They are built against a single copy of go.mod, and
+// rebuilt once if a later build resolved new requirements into it, since
+// plugin.Open refuses plugins linking other versions of a shared package.
+func BuildLocal(root string, pipelines []internal.PipelineConfig) (*Build, error) {
+	modfile, err := copyModFile(root)
+	if err != nil {
+		return nil, err
+	}
+	for pass := 0; ; pass++ {
+		before, err := readModFile(modfile)
+		if err != nil {
+			return nil, err
+		}
+		b := &Build{Plugins: make(map[string]string)}
+		for _, pipeline := range pipelines {
+			if b.Plugins[pipeline.Name], err = buildPlugin(root, modfile, pipeline); err != nil {
+				return nil, err
+			}
+		}
+		if b.Runner, err = buildRunner(root, modfile); err != nil {
+			return nil, err
+		}
+		after, err := readModFile(modfile)
+		if err != nil {
+			return nil, err
+		}
+		if pass > 0 || bytes.Equal(before, after) {
+			return b, nil
+		}
+	}
+}
+
+// buildPlugin compiles the folder of a pipeline of the project at root as a
+// Go plugin, resolving missing requirements into modfile.
+func buildPlugin(root, modfile string, pipeline internal.PipelineConfig) (string, error) {
+	out, err := filepath.Abs(PluginPath(root, pipeline.Name))
+	if err != nil {
+		return "", err
+	}
+	if err = goBuild(root, pipeline.Name, modFlags(modfile), "-buildmode=plugin", "-o", out, "./"+pipeline.Dir); err != nil {
+		return "", err
+	}
+	return out, nil
+}
+
+// CheckPlugin compiles a pipeline as a Go plugin like BuildLocal, but
+// strictly against the requirements of go.mod, as the hosted service does:
+// go.mod and go.sum are left untouched and must be complete.
+func CheckPlugin(root string, pipeline internal.PipelineConfig) error {
+	out, err := filepath.Abs(PluginPath(root, pipeline.Name))
+	if err != nil {
+		return err
+	}
+	return goBuild(root, pipeline.Name, []string{"-mod=readonly"}, "-buildmode=plugin", "-o", out, "./"+pipeline.Dir)
+}
+
+// buildRunner generates the runner program inside the project at root and
+// compiles it, resolving missing requirements into modfile.
+func buildRunner(root, modfile string) (string, error) {
+	dir := filepath.Join(root, localdb.StateDir, runnerDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	source := filepath.Join(dir, runnerSource)
+	if err := os.WriteFile(source, []byte(internal.RunnerTemplate), 0644); err != nil {
+		return "", err
+	}
+
+	out, err := filepath.Abs(filepath.Join(root, localdb.StateDir, buildDir, runnerBinary))
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(root, source)
+	if err != nil {
+		return "", err
+	}
+	// the runner is built as a list of files since .zrunner is not a valid
+	// package path element
+	if err = goBuild(root, "runner", modFlags(modfile), "-o", out, rel); err != nil {
+		return "", err
+	}
+	return out, nil
+}
+
+// goBuild runs go build in root with the given -mod flags.
+func goBuild(root, target string, modArgs []string, args ...string) error {
+	cmd := exec.Command("go", append(append([]string{"build"}, modArgs...), args...)...)
+	cmd.Dir = root
+	output := &bytes.Buffer{}
+	cmd.Stdout = output
+	cmd.Stderr = output
+	if err := cmd.Run(); err != nil {
+		if _, ok := err.(*exec.ExitError); ok {
+			return &BuildError{Target: target, Output: output.String()}
+		}
+		return err
+	}
+	return nil
+}
+
+// modFlags resolves requirements into modfile, so that the project's own
+// go.mod and go.sum are never modified. Without modfile, in a go.work
+// workspace where -mod=mod is not allowed, dependencies are left to the
+// workspace.
+func modFlags(modfile string) []string {
+	if modfile == "" {
+		return nil
+	}
+	return []string{"-mod=mod", "-modfile=" + modfile}
+}
+
+// readModFile returns the content of modfile, nil if it is empty.
+func readModFile(modfile string) ([]byte, error) {
+	if modfile == "" {
+		return nil, nil
+	}
+	return os.ReadFile(modfile)
+}
+
+// copyModFile copies go.mod and go.sum of the project at root under
+// .zrunner/build, and returns the absolute path of the go.mod copy. In a
+// go.work workspace, nothing is copied and the path is empty.
+func copyModFile(root string) (string, error) {
+	work, err := gomod.Workspace(root)
+	if err != nil || work != "" {
+		return "", err
+	}
+	dir, err := filepath.Abs(filepath.Join(root, localdb.StateDir, buildDir))
+	if err != nil {
+		return "", err
+	}
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	for src, dst := range map[string]string{"go.mod": modFile, "go.sum": sumFile} {
+		data, err := os.ReadFile(filepath.Join(root, src))
+		if os.IsNotExist(err) && src == "go.sum" {
+			data = nil
+		} else if err != nil {
+			return "", err
+		}
+		if err = os.WriteFile(filepath.Join(dir, dst), data, 0644); err != nil {
+			return "", err
+		}
+	}
+	return filepath.Join(dir, modFile), nil
+}
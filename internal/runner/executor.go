@@ -0,0 +1,150 @@
+package runner
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+
+	"github.com/Zettablock/zetta-go/internal/localdb"
+)
+
+// Invocation asks the runner program to call a handler. Block carries the
+// block number for handlers taking an int, int64 or string, and Data the
+// record for handlers taking a zsource DAO type.
+type Invocation struct {
+	ID       uint64          `json:"id"`
+	Pipeline string          `json:"pipeline"`
+	Handler  string          `json:"handler"`
+	Block    int64           `json:"block"`
+	Data     json.RawMessage `json:"data,omitempty"`
+}
+
+// Result is what a handler returned.
+type Result struct {
+	ID    uint64 `json:"id"`
+	Retry bool   `json:"retry"`
+	Error string `json:"error,omitempty"`
+}
+
+// Executor is a running runner program. Invoke may be called concurrently.
+type Executor struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+
+	mu      sync.Mutex
+	nextID  uint64
+	pending map[uint64]chan Result
+	err     error
+	done    chan struct{}
+}
+
+// Start launches the runner program with the plugins of every pipeline,
+// keyed by pipeline name, adding env, as NAME=value, to its environment.
+// Handler logs are written to stderr.
+func Start(runnerPath string, inst *localdb.Instance, plugins map[string]string, env []string, stderr io.Writer) (*Executor, error) {
+	args := []string{"-source", inst.SourceDSN(), "-destination", inst.DestinationDSN()}
+	for name, path := range plugins {
+		args = append(args, fmt.Sprintf("%s=%s", name, path))
+	}
+
+	cmd := exec.Command(runnerPath, args...)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stderr = stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err = cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	e := &Executor{
+		cmd:     cmd,
+		stdin:   stdin,
+		pending: make(map[uint64]chan Result),
+		done:    make(chan struct{}),
+	}
+	go e.read(stdout)
+	return e, nil
+}
+
+func (e *Executor) read(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(nil, 16*1024*1024)
+	for scanner.Scan() {
+		res := Result{}
+		if err := json.Unmarshal(scanner.Bytes(), &res); err != nil {
+			continue
+		}
+		e.mu.Lock()
+		ch, ok := e.pending[res.ID]
+		delete(e.pending, res.ID)
+		e.mu.Unlock()
+		if ok {
+			ch <- res
+		}
+	}
+
+	err := e.cmd.Wait()
+	if err == nil {
+		err = errors.New("runner exited")
+	}
+	e.mu.Lock()
+	e.err = fmt.Errorf("runner stopped: %w", err)
+	e.mu.Unlock()
+	close(e.done)
+}
+
+// Invoke calls a handler and waits for its result.
+func (e *Executor) Invoke(ctx context.Context, inv Invocation) (Result, error) {
+	ch := make(chan Result, 1)
+
+	e.mu.Lock()
+	if e.err != nil {
+		e.mu.Unlock()
+		return Result{}, e.err
+	}
+	e.nextID++
+	inv.ID = e.nextID
+	e.pending[inv.ID] = ch
+	line, err := json.Marshal(inv)
+	if err == nil {
+		_, err = e.stdin.Write(append(line, '\n'))
+	}
+	if err != nil {
+		delete(e.pending, inv.ID)
+	}
+	e.mu.Unlock()
+	if err != nil {
+		return Result{}, err
+	}
+
+	select {
+	case res := <-ch:
+		return res, nil
+	case <-e.done:
+		return Result{}, e.err
+	case <-ctx.Done():
+		e.mu.Lock()
+		delete(e.pending, inv.ID)
+		e.mu.Unlock()
+		return Result{}, ctx.Err()
+	}
+}
+
+// Close stops the runner program once in-flight invocations are done.
+func (e *Executor) Close() error {
+	e.stdin.Close()
+	<-e.done
+	return nil
+}
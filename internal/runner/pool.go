@@ -0,0 +1,254 @@
+package runner
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Zettablock/zetta-go/internal"
+	"github.com/Zettablock/zetta-go/internal/dlq"
+	"github.com/Zettablock/zetta-go/internal/fixtures"
+	"github.com/Zettablock/zetta-go/internal/state"
+)
+
+// pipelineRun processes blocks for one pipeline with a pool of workers.
+type pipelineRun struct {
+	pipeline internal.PipelineConfig
+	exec     *Executor
+	state    *state.Store
+	summary  *Summary
+	policy   *internal.RetryPolicy
+	dlq      *dlq.Store
+	// blocks up to after are skipped when resume is set
+	after  int64
+	resume bool
+}
+
+// job is a block handed to a worker. prev is closed once the ordered
+// handlers of the previous block are done, and done once those of this
+// block are.
+type job struct {
+	index int
+	block []fixtures.Record
+	prev  <-chan struct{}
+	done  chan struct{}
+}
+
+func (p *pipelineRun) run(ctx context.Context, all [][]fixtures.Record) error {
+	var blocks [][]fixtures.Record
+	for _, block := range all {
+		number := block[0].Block
+		if number < p.pipeline.Source.StartBlock || (p.resume && number <= p.after) {
+			continue
+		}
+		blocks = append(blocks, block)
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	workers := max(p.pipeline.Parallelism, 1)
+	ordered := orderedHandlers(p.pipeline)
+	progress := &progress{done: make(map[int]bool)}
+
+	// the queue holds at most one block per worker, so that reading ahead
+	// is bounded
+	jobs := make(chan job, workers)
+	go func() {
+		defer close(jobs)
+		prev := make(chan struct{})
+		close(prev)
+		for i, block := range blocks {
+			j := job{index: i, block: block, prev: prev, done: make(chan struct{})}
+			select {
+			case jobs <- j:
+			case <-ctx.Done():
+				return
+			}
+			prev = j.done
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				if ctx.Err() != nil {
+					continue
+				}
+				if err := p.process(ctx, j, ordered); err != nil {
+					cancel(err)
+					continue
+				}
+				for _, i := range progress.complete(j.index) {
+					p.processed(blocks[i][0].Block)
+				}
+				if p.state != nil {
+					if err := p.state.Checkpoint(); err != nil {
+						cancel(err)
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	return context.Cause(ctx)
+}
+
+// process invokes the handlers of the pipeline for a block, in plan order.
+// Before its first ordered handler, it waits for those of the previous
+// block.
+func (p *pipelineRun) process(ctx context.Context, j job, ordered map[string]bool) error {
+	tasks := Plan(p.pipeline, j.block)
+	last := -1
+	for i, task := range tasks {
+		if ordered[task.Handler] {
+			last = i
+		}
+	}
+	if last < 0 {
+		go func() {
+			select {
+			case <-j.prev:
+				close(j.done)
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	for i, task := range tasks {
+		if ordered[task.Handler] && i <= last && j.prev != nil {
+			select {
+			case <-j.prev:
+				j.prev = nil
+			case <-ctx.Done():
+				return context.Cause(ctx)
+			}
+		}
+		if err := p.invoke(ctx, task); err != nil {
+			return err
+		}
+		if i == last {
+			close(j.done)
+		}
+	}
+	return nil
+}
+
+// invoke runs a task, retrying it as the retry policy says. A task whose
+// retries are exhausted is dead-lettered, and is not an error.
+func (p *pipelineRun) invoke(ctx context.Context, task Task) error {
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		res, err := p.exec.Invoke(ctx, Invocation{
+			Pipeline: task.Pipeline,
+			Handler:  task.Handler,
+			Block:    task.Record.Block,
+			Data:     task.Record.Data,
+		})
+		if err != nil {
+			return err
+		}
+		p.summary.invoked(task, time.Since(start))
+
+		if res.Error == "" && !res.Retry {
+			if p.state != nil {
+				p.state.Invoked(task.Pipeline, task.Handler, task.Record.Block, "")
+			}
+			return nil
+		}
+
+		herr := &HandlerError{Task: task, Err: res.Error, Retry: res.Retry, Attempts: attempt}
+		if p.state != nil {
+			p.state.Invoked(task.Pipeline, task.Handler, task.Record.Block, herr.Error())
+		}
+		if !p.policy.Retryable(res.Error, res.Retry) {
+			if err = p.deadLetter(herr); err != nil {
+				return err
+			}
+			return herr
+		}
+		if attempt >= p.policy.MaxAttempts {
+			return p.deadLetter(herr)
+		}
+
+		timer := time.NewTimer(p.policy.Delay(attempt))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return context.Cause(ctx)
+		}
+	}
+}
+
+func (p *pipelineRun) deadLetter(herr *HandlerError) error {
+	p.summary.deadLettered()
+	if p.dlq == nil {
+		return nil
+	}
+	errText := herr.Err
+	if errText == "" {
+		errText = "asked to be retried"
+	}
+	return p.dlq.Append(dlq.Entry{
+		Pipeline: herr.Task.Pipeline,
+		Handler:  herr.Task.Handler,
+		Block:    herr.Task.Record.Block,
+		Record:   herr.Task.Record,
+		Error:    errText,
+		Attempts: herr.Attempts,
+		Time:     time.Now().UTC(),
+	})
+}
+
+// processed records that every handler of the pipeline is done with a block
+// and with all the blocks before it.
+func (p *pipelineRun) processed(block int64) {
+	p.summary.processed(block)
+	if p.state != nil {
+		p.state.Processed(p.pipeline.Name, block)
+	}
+}
+
+// orderedHandlers returns the handlers of a pipeline declared ordered.
+func orderedHandlers(pipeline internal.PipelineConfig) map[string]bool {
+	ordered := make(map[string]bool)
+	for _, h := range pipeline.BlockHandlers {
+		if h.Ordered {
+			ordered[h.Handler] = true
+		}
+	}
+	for _, h := range pipeline.EventHandlers {
+		if h.Ordered {
+			ordered[h.Handler] = true
+		}
+	}
+	return ordered
+}
+
+// progress tracks the blocks done out of order, to report them in order.
+type progress struct {
+	mu   sync.Mutex
+	next int
+	done map[int]bool
+}
+
+// complete marks the block at index done and returns the indexes of the
+// blocks now done in sequence.
+func (p *progress) complete(index int) []int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.done[index] = true
+	var completed []int
+	for p.done[p.next] {
+		delete(p.done, p.next)
+		completed = append(completed, p.next)
+		p.next++
+	}
+	return completed
+}
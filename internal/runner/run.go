@@ -0,0 +1,231 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Zettablock/zetta-go/internal"
+	"github.com/Zettablock/zetta-go/internal/dlq"
+	"github.com/Zettablock/zetta-go/internal/fixtures"
+	"github.com/Zettablock/zetta-go/internal/state"
+)
+
+// Task is one handler invocation of a pipeline, for a block or log record.
+type Task struct {
+	Pipeline string
+	Handler  string
+	Record   fixtures.Record
+}
+
+// Plan returns the invocations of a pipeline for the records of one block:
+// every block handler for the block record, then every event handler for
+// each matching log, in log order.
+func Plan(pipeline internal.PipelineConfig, block []fixtures.Record) []Task {
+	var tasks []Task
+	for _, r := range block {
+		if r.Type != fixtures.TypeBlock {
+			continue
+		}
+		for _, h := range pipeline.BlockHandlers {
+			tasks = append(tasks, Task{pipeline.Name, h.Handler, r})
+		}
+	}
+
+	for _, r := range block {
+		if r.Type != fixtures.TypeLog {
+			continue
+		}
+		row, err := r.Row()
+		if err != nil || !matchAddress(pipeline.Source.Addresses, row) {
+			continue
+		}
+		for _, h := range pipeline.EventHandlers {
+			if matchEvent(h.Event, row) {
+				tasks = append(tasks, Task{pipeline.Name, h.Handler, r})
+			}
+		}
+	}
+	return tasks
+}
+
+func matchAddress(addresses []string, row map[string]any) bool {
+	if len(addresses) == 0 {
+		return true
+	}
+	contract, _ := row["contract_address"].(string)
+	for _, address := range addresses {
+		if strings.EqualFold(address, contract) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchEvent matches a decoded log by its event name, or by the name part of
+// its signature.
+func matchEvent(event string, row map[string]any) bool {
+	for _, column := range []string{"event", "event_name"} {
+		if name, ok := row[column].(string); ok && name != "" {
+			return name == event
+		}
+	}
+	if signature, ok := row["signature"].(string); ok {
+		name, _, _ := strings.Cut(signature, "(")
+		return strings.TrimSpace(name) == event
+	}
+	return false
+}
+
+// Tasks returns the invocations of a pipeline for every block of records, in
+// processing order, from the pipeline start block.
+func Tasks(pipeline internal.PipelineConfig, records []fixtures.Record) []Task {
+	var tasks []Task
+	for _, block := range groupByBlock(records) {
+		if block[0].Block >= pipeline.Source.StartBlock {
+			tasks = append(tasks, Plan(pipeline, block)...)
+		}
+	}
+	return tasks
+}
+
+// Options configures a local run.
+type Options struct {
+	Pipelines []internal.PipelineConfig
+	Records   []fixtures.Record
+	From      int64
+	To        int64
+	// State, if set, records the progress of every pipeline.
+	State *state.Store
+	// Resume skips, for every pipeline, the blocks State has recorded as
+	// processed.
+	Resume bool
+	// DeadLetters, if set, receives the invocations that failed for good.
+	DeadLetters *dlq.Store
+}
+
+// HandlerError is a handler invocation that failed.
+type HandlerError struct {
+	Task     Task
+	Err      string
+	Retry    bool
+	Attempts int
+}
+
+func (e *HandlerError) Error() string {
+	attempts := ""
+	if e.Attempts > 1 {
+		attempts = fmt.Sprintf(" after %d attempts", e.Attempts)
+	}
+	if e.Err == "" && e.Retry {
+		return fmt.Sprintf("%s/%s at block %d asked to be retried%s", e.Task.Pipeline, e.Task.Handler, e.Task.Record.Block, attempts)
+	}
+	return fmt.Sprintf("%s/%s at block %d%s: %s", e.Task.Pipeline, e.Task.Handler, e.Task.Record.Block, attempts, e.Err)
+}
+
+// Run invokes the handlers of every pipeline for each block of the records
+// within [From, To]. Pipelines run concurrently, each over up to its
+// parallelism blocks at a time, and handlers declared ordered are invoked in
+// block order. Blocks before the start block of a pipeline are skipped for
+// that pipeline. Failed invocations are retried as the retry policy of their
+// pipeline says. Once retries are exhausted, the invocation is
+// dead-lettered and the run goes on. Otherwise, the run stops at the first
+// failed invocation, or when ctx is done.
+func Run(ctx context.Context, exec *Executor, opts Options) (summary *Summary, err error) {
+	start := time.Now()
+	summary = newSummary()
+	defer func() { summary.Elapsed = time.Since(start) }()
+	if opts.State != nil {
+		defer func() {
+			if saveErr := opts.State.Save(); err == nil {
+				err = saveErr
+			}
+		}()
+	}
+
+	policies := make(map[string]*internal.RetryPolicy)
+	for _, pipeline := range opts.Pipelines {
+		if policies[pipeline.Name], err = pipeline.RetryPolicy(); err != nil {
+			return summary, fmt.Errorf("pipeline %s: %w", pipeline.Name, err)
+		}
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	blocks := groupByBlock(fixtures.Slice(opts.Records, opts.From, opts.To))
+	var wg sync.WaitGroup
+	for _, pipeline := range opts.Pipelines {
+		p := &pipelineRun{
+			pipeline: pipeline,
+			exec:     exec,
+			state:    opts.State,
+			summary:  summary,
+			policy:   policies[pipeline.Name],
+			dlq:      opts.DeadLetters,
+		}
+		if opts.State != nil && opts.Resume {
+			p.after, p.resume = opts.State.Resume(pipeline.Name)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := p.run(ctx, blocks); err != nil {
+				cancel(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return summary, context.Cause(ctx)
+}
+
+// groupByBlock splits records, in processing order, by block.
+func groupByBlock(records []fixtures.Record) [][]fixtures.Record {
+	var blocks [][]fixtures.Record
+	for i, r := range records {
+		if i == 0 || records[i-1].Block != r.Block {
+			blocks = append(blocks, nil)
+		}
+		blocks[len(blocks)-1] = append(blocks[len(blocks)-1], r)
+	}
+	return blocks
+}
+
+// Rollback invokes the reorgHandler of a pipeline with the first block of a
+// reorganisation.
+func Rollback(ctx context.Context, exec *Executor, pipeline internal.PipelineConfig, from int64) error {
+	res, err := exec.Invoke(ctx, Invocation{
+		Pipeline: pipeline.Name,
+		Handler:  pipeline.ReorgHandler,
+		Block:    from,
+	})
+	if err != nil {
+		return err
+	}
+	if res.Error != "" || res.Retry {
+		task := Task{Pipeline: pipeline.Name, Handler: pipeline.ReorgHandler, Record: fixtures.Record{Block: from}}
+		return &HandlerError{Task: task, Err: res.Error, Retry: res.Retry}
+	}
+	return nil
+}
+
+// Replay invokes the handler of a dead-lettered invocation again, once.
+func Replay(ctx context.Context, exec *Executor, entry dlq.Entry) error {
+	task := Task{Pipeline: entry.Pipeline, Handler: entry.Handler, Record: entry.Record}
+	res, err := exec.Invoke(ctx, Invocation{
+		Pipeline: task.Pipeline,
+		Handler:  task.Handler,
+		Block:    task.Record.Block,
+		Data:     task.Record.Data,
+	})
+	if err != nil {
+		return err
+	}
+	if res.Error != "" || res.Retry {
+		return &HandlerError{Task: task, Err: res.Error, Retry: res.Retry}
+	}
+	return nil
+}
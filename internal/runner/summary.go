@@ -0,0 +1,130 @@
+package runner
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Summary describes a finished run.
+type Summary struct {
+	Blocks      int
+	Invocations int
+	DeadLetters int
+	Elapsed     time.Duration
+
+	mu        sync.Mutex
+	blocks    map[int64]bool
+	latencies map[handlerKey][]time.Duration
+}
+
+type handlerKey struct {
+	pipeline string
+	handler  string
+}
+
+// HandlerStats summarises the invocations of a handler.
+type HandlerStats struct {
+	Pipeline    string
+	Handler     string
+	Invocations int
+	P50         time.Duration
+	P99         time.Duration
+}
+
+func newSummary() *Summary {
+	return &Summary{
+		blocks:    make(map[int64]bool),
+		latencies: make(map[handlerKey][]time.Duration),
+	}
+}
+
+// invoked records a handler invocation and how long it took.
+func (s *Summary) invoked(task Task, latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := handlerKey{task.Pipeline, task.Handler}
+	s.latencies[key] = append(s.latencies[key], latency)
+	s.Invocations++
+}
+
+// deadLettered records an invocation that failed for good.
+func (s *Summary) deadLettered() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.DeadLetters++
+}
+
+// processed records that a pipeline is done with a block.
+func (s *Summary) processed(block int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.blocks[block] {
+		s.blocks[block] = true
+		s.Blocks++
+	}
+}
+
+// Add accumulates the counts of another run, such as a later phase of the
+// same run.
+func (s *Summary) Add(other *Summary) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	other.mu.Lock()
+	defer other.mu.Unlock()
+
+	s.Blocks += other.Blocks
+	s.Invocations += other.Invocations
+	s.DeadLetters += other.DeadLetters
+	s.Elapsed += other.Elapsed
+	for key, latencies := range other.latencies {
+		s.latencies[key] = append(s.latencies[key], latencies...)
+	}
+}
+
+// Throughput returns the blocks processed per second.
+func (s *Summary) Throughput() float64 {
+	if s.Elapsed <= 0 {
+		return 0
+	}
+	return float64(s.Blocks) / s.Elapsed.Seconds()
+}
+
+// Handlers returns the latency percentiles of every handler invoked, sorted
+// by pipeline and handler.
+func (s *Summary) Handlers() []HandlerStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := make([]HandlerStats, 0, len(s.latencies))
+	for key, latencies := range s.latencies {
+		sorted := append([]time.Duration(nil), latencies...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		stats = append(stats, HandlerStats{
+			Pipeline:    key.pipeline,
+			Handler:     key.handler,
+			Invocations: len(sorted),
+			P50:         percentile(sorted, 50),
+			P99:         percentile(sorted, 99),
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Pipeline != stats[j].Pipeline {
+			return stats[i].Pipeline < stats[j].Pipeline
+		}
+		return stats[i].Handler < stats[j].Handler
+	})
+	return stats
+}
+
+// percentile returns the nearest-rank percentile of sorted latencies.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (len(sorted)*p + 99) / 100
+	return sorted[max(rank, 1)-1]
+}
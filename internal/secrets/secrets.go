@@ -0,0 +1,80 @@
+// Package secrets encrypts secret values for the hosted service, so that
+// only the service can read them.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// Sealed is a value encrypted with AES-256-GCM under a random key, itself
+// encrypted with RSA-OAEP (SHA-256) under the public key of the service. All
+// fields are base64 encoded.
+type Sealed struct {
+	Key        string `json:"encrypted_key"`
+	Nonce      string `json:"nonce"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+// ParsePublicKey reads a PEM encoded RSA public key, in PKIX or PKCS #1 form.
+func ParsePublicKey(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM encoded public key found")
+	}
+	switch block.Type {
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("unsupported public key type %T, expected RSA", key)
+		}
+		return rsaKey, nil
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unexpected PEM block %s", block.Type)
+	}
+}
+
+// Seal encrypts value for the holder of the private key of pub.
+func Seal(pub *rsa.PublicKey, value []byte) (*Sealed, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	encryptedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, pub, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	enc := base64.StdEncoding
+	return &Sealed{
+		Key:        enc.EncodeToString(encryptedKey),
+		Nonce:      enc.EncodeToString(nonce),
+		Ciphertext: enc.EncodeToString(gcm.Seal(nil, nonce, value, nil)),
+	}, nil
+}
@@ -0,0 +1,261 @@
+// Package snapshot dumps destination tables as canonical JSON and compares
+// dumps, for golden-output tests of pipelines.
+package snapshot
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/lib/pq"
+)
+
+// Snapshot holds the rows of every table, each row encoded as canonical JSON
+// (sorted keys, UTC timestamps) and rows sorted.
+type Snapshot map[string][]string
+
+// Dump reads every table of schema. The columns listed in exclude, as column
+// for every table or table.column, are left out.
+func Dump(db *sql.DB, schema string, exclude []string) (Snapshot, error) {
+	rows, err := db.Query("SELECT table_name FROM information_schema.tables WHERE table_schema = $1 AND table_type = 'BASE TABLE' ORDER BY table_name", schema)
+	if err != nil {
+		return nil, err
+	}
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err = rows.Scan(&table); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	snap := Snapshot{}
+	for _, table := range tables {
+		if snap[table], err = dumpTable(db, schema, table, excluded(exclude, table)); err != nil {
+			return nil, fmt.Errorf("%s: %w", table, err)
+		}
+	}
+	return snap, nil
+}
+
+// excluded returns the columns of table listed in exclude.
+func excluded(exclude []string, table string) map[string]bool {
+	columns := map[string]bool{}
+	for _, column := range exclude {
+		if t, c, ok := strings.Cut(column, "."); !ok {
+			columns[column] = true
+		} else if t == table {
+			columns[c] = true
+		}
+	}
+	return columns
+}
+
+func dumpTable(db *sql.DB, schema, table string, exclude map[string]bool) ([]string, error) {
+	rows, err := db.Query(fmt.Sprintf("SELECT * FROM %s.%s", pq.QuoteIdentifier(schema), pq.QuoteIdentifier(table)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	dumped := []string{}
+	for rows.Next() {
+		values := make([]any, len(columns))
+		pointers := make([]any, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err = rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+
+		row := make(map[string]any, len(columns))
+		for i, column := range columns {
+			if !exclude[column] {
+				row[column] = canonical(values[i])
+			}
+		}
+		b, err := json.Marshal(row)
+		if err != nil {
+			return nil, err
+		}
+		dumped = append(dumped, string(b))
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Strings(dumped)
+	return dumped, nil
+}
+
+func canonical(value any) any {
+	switch v := value.(type) {
+	case []byte:
+		if utf8.Valid(v) {
+			return string(v)
+		}
+		return base64.StdEncoding.EncodeToString(v)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	default:
+		return v
+	}
+}
+
+// Write stores the snapshot in dir, one <table>.json file per table with one
+// row per line. Files of tables no longer in the snapshot are removed.
+func Write(dir string, snap Snapshot) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	existing, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, file := range existing {
+		if _, ok := snap[strings.TrimSuffix(filepath.Base(file), ".json")]; !ok {
+			if err = os.Remove(file); err != nil {
+				return err
+			}
+		}
+	}
+
+	for table, rows := range snap {
+		buf := &bytes.Buffer{}
+		buf.WriteString("[")
+		for i, row := range rows {
+			if i > 0 {
+				buf.WriteString(",")
+			}
+			buf.WriteString("\n  ")
+			buf.WriteString(row)
+		}
+		buf.WriteString("\n]\n")
+		if err = os.WriteFile(filepath.Join(dir, table+".json"), buf.Bytes(), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Read loads a snapshot written by Write.
+func Read(dir string) (Snapshot, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no snapshot found in %s", dir)
+	}
+
+	snap := Snapshot{}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var rows []json.RawMessage
+		if err = json.Unmarshal(data, &rows); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		table := strings.TrimSuffix(filepath.Base(file), ".json")
+		snap[table] = []string{}
+		for _, row := range rows {
+			buf := &bytes.Buffer{}
+			if err = json.Compact(buf, row); err != nil {
+				return nil, err
+			}
+			snap[table] = append(snap[table], buf.String())
+		}
+		sort.Strings(snap[table])
+	}
+	return snap, nil
+}
+
+// TableDiff lists the rows of a table missing from, or unexpected in, the
+// actual snapshot.
+type TableDiff struct {
+	Table      string
+	Missing    []string
+	Unexpected []string
+}
+
+// Diff compares actual against expected, table by table.
+func Diff(expected, actual Snapshot) []TableDiff {
+	tables := map[string]bool{}
+	for table := range expected {
+		tables[table] = true
+	}
+	for table := range actual {
+		tables[table] = true
+	}
+	names := make([]string, 0, len(tables))
+	for table := range tables {
+		names = append(names, table)
+	}
+	sort.Strings(names)
+
+	var diffs []TableDiff
+	for _, table := range names {
+		counts := map[string]int{}
+		for _, row := range expected[table] {
+			counts[row]++
+		}
+		for _, row := range actual[table] {
+			counts[row]--
+		}
+
+		diff := TableDiff{Table: table}
+		for _, row := range expected[table] {
+			if counts[row] > 0 {
+				diff.Missing = append(diff.Missing, row)
+				counts[row]--
+			}
+		}
+		for _, row := range actual[table] {
+			if counts[row] < 0 {
+				diff.Unexpected = append(diff.Unexpected, row)
+				counts[row]++
+			}
+		}
+		if len(diff.Missing) > 0 || len(diff.Unexpected) > 0 {
+			diffs = append(diffs, diff)
+		}
+	}
+	return diffs
+}
+
+// Report formats diffs for humans.
+func Report(diffs []TableDiff) string {
+	buf := &strings.Builder{}
+	for _, diff := range diffs {
+		fmt.Fprintf(buf, "%s: %d missing, %d unexpected rows\n", diff.Table, len(diff.Missing), len(diff.Unexpected))
+		for _, row := range diff.Missing {
+			fmt.Fprintf(buf, "- %s\n", row)
+		}
+		for _, row := range diff.Unexpected {
+			fmt.Fprintf(buf, "+ %s\n", row)
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,250 @@
+// Package state persists the progress of local runs, per pipeline, so that
+// interrupted runs can be resumed.
+package state
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Zettablock/zetta-go/internal/localdb"
+)
+
+const (
+	stateDir = "state"
+
+	// checkpointInterval bounds how often Checkpoint writes to disk.
+	checkpointInterval = time.Second
+)
+
+// Handler is the progress of one handler of a pipeline.
+type Handler struct {
+	// Cursor is the last block the handler was successfully invoked for.
+	Cursor      int64  `json:"cursor"`
+	Invocations int64  `json:"invocations"`
+	Failures    int64  `json:"failures"`
+	LastError   string `json:"lastError,omitempty"`
+}
+
+// Pipeline is the progress of a pipeline.
+type Pipeline struct {
+	Name string `json:"name"`
+	// LastBlock is the last block every handler of the pipeline has been
+	// invoked for. It is only meaningful once Blocks is not zero.
+	LastBlock int64               `json:"lastBlock"`
+	Blocks    int64               `json:"blocks"`
+	Handlers  map[string]*Handler `json:"handlers"`
+	UpdatedAt time.Time           `json:"updatedAt"`
+
+	dirty bool
+}
+
+// Store holds the progress of the pipelines of a project, one
+// .zrunner/state/<pipeline>.json file per pipeline. It is safe for
+// concurrent use.
+type Store struct {
+	dir       string
+	mu        sync.Mutex
+	pipelines map[string]*Pipeline
+	saved     time.Time
+}
+
+// Open loads the progress recorded for the project at root.
+func Open(root string) (*Store, error) {
+	s := &Store{
+		dir:       filepath.Join(root, localdb.StateDir, stateDir),
+		pipelines: make(map[string]*Pipeline),
+		saved:     time.Now(),
+	}
+
+	files, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		p := &Pipeline{}
+		if err = json.Unmarshal(data, p); err != nil {
+			return nil, &os.PathError{Op: "read state", Path: file, Err: err}
+		}
+		if p.Handlers == nil {
+			p.Handlers = make(map[string]*Handler)
+		}
+		s.pipelines[p.Name] = p
+	}
+	return s, nil
+}
+
+// Pipelines returns a copy of the recorded progress, sorted by pipeline name.
+func (s *Store) Pipelines() []Pipeline {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pipelines := make([]Pipeline, 0, len(s.pipelines))
+	for _, p := range s.pipelines {
+		c := *p
+		c.Handlers = make(map[string]*Handler, len(p.Handlers))
+		for name, h := range p.Handlers {
+			hc := *h
+			c.Handlers[name] = &hc
+		}
+		pipelines = append(pipelines, c)
+	}
+	sort.Slice(pipelines, func(i, j int) bool { return pipelines[i].Name < pipelines[j].Name })
+	return pipelines
+}
+
+// Resume returns the block after which a pipeline should resume, and whether
+// it has processed any block.
+func (s *Store) Resume(pipeline string) (int64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.pipelines[pipeline]
+	if !ok || p.Blocks == 0 {
+		return 0, false
+	}
+	return p.LastBlock, true
+}
+
+// Invoked records the outcome of a handler invocation. An empty failure
+// means it succeeded.
+func (s *Store) Invoked(pipeline, handler string, block int64, failure string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := s.pipeline(pipeline)
+	h, ok := p.Handlers[handler]
+	if !ok {
+		h = &Handler{}
+		p.Handlers[handler] = h
+	}
+	h.Invocations++
+	if failure != "" {
+		h.Failures++
+		h.LastError = failure
+	} else if block > h.Cursor {
+		h.Cursor = block
+	}
+	p.UpdatedAt = time.Now().UTC()
+	p.dirty = true
+}
+
+// Processed records that every handler of a pipeline has been invoked for a
+// block.
+func (s *Store) Processed(pipeline string, block int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := s.pipeline(pipeline)
+	if p.Blocks == 0 || block > p.LastBlock {
+		p.LastBlock = block
+	}
+	p.Blocks++
+	p.UpdatedAt = time.Now().UTC()
+	p.dirty = true
+}
+
+func (s *Store) pipeline(name string) *Pipeline {
+	p, ok := s.pipelines[name]
+	if !ok {
+		p = &Pipeline{Name: name, Handlers: make(map[string]*Handler)}
+		s.pipelines[name] = p
+	}
+	return p
+}
+
+// Reset forgets the progress of the given pipelines, or of all of them if
+// none is given.
+func (s *Store) Reset(pipelines ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(pipelines) == 0 {
+		for name := range s.pipelines {
+			pipelines = append(pipelines, name)
+		}
+	}
+	for _, name := range pipelines {
+		delete(s.pipelines, name)
+		err := os.Remove(s.file(name))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Checkpoint saves the progress if it has not been saved for a while.
+func (s *Store) Checkpoint() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if time.Since(s.saved) < checkpointInterval {
+		return nil
+	}
+	return s.save()
+}
+
+// Save writes the progress of every pipeline that changed.
+func (s *Store) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.save()
+}
+
+func (s *Store) save() error {
+	for _, p := range s.pipelines {
+		if !p.dirty {
+			continue
+		}
+		data, err := json.MarshalIndent(p, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err = writeFile(s.file(p.Name), data); err != nil {
+			return err
+		}
+		p.dirty = false
+	}
+	s.saved = time.Now()
+	return nil
+}
+
+func (s *Store) file(pipeline string) string {
+	return filepath.Join(s.dir, strings.ReplaceAll(pipeline, string(filepath.Separator), "_")+".json")
+}
+
+// writeFile replaces path atomically, so that an interrupted run never
+// leaves a truncated state file.
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
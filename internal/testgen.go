@@ -0,0 +1,261 @@
+package internal
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/Zettablock/zetta-go/internal/gomod"
+)
+
+const (
+	testHelpersFile = "zrunner_helpers_test.go"
+	daoDir          = "dao"
+	// testDriverModule is the module the test helpers open the local
+	// database with.
+	testDriverModule = "gorm.io/driver/postgres"
+)
+
+//go:embed templates/helpers_test.go.tmpl
+var testHelpersTemplate string
+
+//go:embed templates/handlers_test.go.tmpl
+var handlersTestTemplate string
+
+// fixture record types of zsource DAO types taken by handlers
+var handlerRecordTypes = map[string]string{
+	"Block":       "block",
+	"Transaction": "transaction",
+	"Log":         "log",
+	"Withdrawal":  "withdrawal",
+}
+
+type handlerTest struct {
+	Name   string
+	Inputs string
+	file   string
+	imp    string
+}
+
+// TestDriverWarning returns why the generated tests of the project at root
+// do not compile if go.mod does not require the database driver they use,
+// and an empty string otherwise.
+func TestDriverWarning(root string) (string, error) {
+	required, err := gomod.Requires(root, testDriverModule)
+	if err != nil || required {
+		return "", err
+	}
+	return fmt.Sprintf("the tests use %s, which go.mod does not require; run go get %s", testDriverModule, testDriverModule), nil
+}
+
+// GenerateTests writes, next to each handler source file of the pipeline, a
+// _test.go file with a table-driven test per handler, plus the helpers they
+// share. Existing test files are kept unless force is set. It returns the
+// files written.
+func GenerateTests(root string, pipeline PipelineConfig, force bool) ([]string, error) {
+	dir := filepath.Join(root, pipeline.Dir)
+
+	tests, err := handlerTests(dir, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	tables, err := destinationTables(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var written []string
+	helpersFile := filepath.Join(dir, testHelpersFile)
+	if _, err = os.Stat(helpersFile); err != nil || force {
+		helpers, err := render(testHelpersTemplate, struct{ Pipeline string }{pipeline.Name})
+		if err != nil {
+			return nil, err
+		}
+		if err = os.WriteFile(helpersFile, helpers, 0644); err != nil {
+			return nil, err
+		}
+		written = append(written, helpersFile)
+	}
+
+	byFile := make(map[string][]handlerTest)
+	for _, test := range tests {
+		byFile[test.file] = append(byFile[test.file], test)
+	}
+	for file, tests := range byFile {
+		testFile := strings.TrimSuffix(file, ".go") + "_test.go"
+		if _, err = os.Stat(testFile); err == nil && !force {
+			continue
+		}
+
+		imports := map[string]bool{}
+		for _, test := range tests {
+			if test.imp != "" {
+				imports[test.imp] = true
+			}
+		}
+		data := struct {
+			Imports  []string
+			Handlers []handlerTest
+			Tables   []string
+		}{Handlers: tests, Tables: tables}
+		for imp := range imports {
+			data.Imports = append(data.Imports, imp)
+		}
+		sort.Strings(data.Imports)
+
+		code, err := render(handlersTestTemplate, data)
+		if err != nil {
+			return nil, err
+		}
+		if err = os.WriteFile(testFile, code, 0644); err != nil {
+			return nil, err
+		}
+		written = append(written, testFile)
+	}
+
+	sort.Strings(written)
+	return written, nil
+}
+
+// handlerTests finds the declaration of every handler of the pipeline and
+// derives how its test reads inputs from a fixture.
+func handlerTests(dir string, pipeline PipelineConfig) ([]handlerTest, error) {
+	var names []string
+	for _, h := range pipeline.BlockHandlers {
+		names = append(names, h.Handler)
+	}
+	for _, h := range pipeline.EventHandlers {
+		names = append(names, h.Handler)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	decls := make(map[string]handlerTest)
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, file, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv != nil || fn.Type.Params.NumFields() != 2 {
+				continue
+			}
+			test := handlerTest{Name: fn.Name.Name, file: file}
+			test.Inputs, test.imp = handlerInputs(f, fn)
+			decls[fn.Name.Name] = test
+		}
+	}
+
+	var tests []handlerTest
+	seen := map[string]bool{}
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		test, ok := decls[name]
+		if !ok {
+			return nil, fmt.Errorf("handler %s of pipeline %s not found in %s", name, pipeline.Name, dir)
+		}
+		if test.Inputs == "" {
+			// not a handler signature that can be fed from fixtures
+			continue
+		}
+		tests = append(tests, test)
+	}
+	return tests, nil
+}
+
+// handlerInputs returns the expression reading the inputs of a handler from
+// a fixture, and the import it needs.
+func handlerInputs(f *ast.File, fn *ast.FuncDecl) (string, string) {
+	switch param := fn.Type.Params.List[0].Type.(type) {
+	case *ast.Ident:
+		switch param.Name {
+		case "int", "int64", "string":
+			return fmt.Sprintf("blockNumbers[%s](t, tt.fixture)", param.Name), ""
+		}
+	case *ast.SelectorExpr:
+		pkg, ok := param.X.(*ast.Ident)
+		recordType, known := handlerRecordTypes[param.Sel.Name]
+		if !ok || !known {
+			return "", ""
+		}
+		imp := ""
+		for _, spec := range f.Imports {
+			path, _ := strconv.Unquote(spec.Path.Value)
+			if spec.Name != nil && spec.Name.Name == pkg.Name {
+				imp = spec.Name.Name + " " + spec.Path.Value
+			} else if spec.Name == nil && filepath.Base(path) == pkg.Name {
+				imp = spec.Path.Value
+			}
+		}
+		return fmt.Sprintf("readFixture[%s.%s](t, tt.fixture, %q)", pkg.Name, param.Sel.Name, recordType), imp
+	}
+	return "", ""
+}
+
+var (
+	tableNameConst  = regexp.MustCompile(`const TableName\w+ = "([^"]+)"`)
+	createTableStmt = regexp.MustCompile(`(?i)create\s+table\s+(?:if\s+not\s+exists\s+)?"?([\w.]+)"?`)
+)
+
+// destinationTables lists the tables generated by ormgen under dao, or, if
+// ormgen has not been run yet, those of the schema files.
+func destinationTables(root string) ([]string, error) {
+	pattern, re := filepath.Join(root, daoDir, "*.gen.go"), tableNameConst
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		re = createTableStmt
+		if files, err = filepath.Glob(filepath.Join(root, schemasDir, "*.sql")); err != nil {
+			return nil, err
+		}
+	}
+
+	var tables []string
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range re.FindAllSubmatch(data, -1) {
+			tables = append(tables, strconv.Quote(string(match[1])))
+		}
+	}
+	sort.Strings(tables)
+	return tables, nil
+}
+
+func render(text string, data any) ([]byte, error) {
+	tmpl, err := template.New("test").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	if err = tmpl.Execute(buf, data); err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
@@ -0,0 +1,140 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const workspaceYml = "zrunner-workspace.yml"
+
+// Workspace is a repository holding several zrunner projects, listed in
+// zrunner-workspace.yml:
+//
+//	projects:
+//	  - ethereum
+//	  - chains/story
+type Workspace struct {
+	// Root is the absolute path of the folder of zrunner-workspace.yml.
+	Root     string
+	Projects []WorkspaceProject
+}
+
+// WorkspaceProject is a project of a workspace.
+type WorkspaceProject struct {
+	// Name is the name of project.yml.
+	Name string
+	// Path is the folder of the project, relative to the workspace.
+	Path string
+	Root string
+}
+
+type workspaceFile struct {
+	Projects []string `yaml:"projects"`
+}
+
+// FindWorkspace returns the workspace of dir or, unless exact is set, of its
+// nearest parent with a zrunner-workspace.yml. It returns nil if there is
+// none.
+func FindWorkspace(dir string, exact bool) (*Workspace, error) {
+	for {
+		path := filepath.Join(dir, workspaceYml)
+		if _, err := os.Stat(path); err == nil {
+			return readWorkspace(path)
+		}
+		parent := filepath.Dir(dir)
+		if exact || parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+func readWorkspace(path string) (*Workspace, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	file := workspaceFile{}
+	if err = yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(file.Projects) == 0 {
+		return nil, fmt.Errorf("%s: no project listed", path)
+	}
+
+	ws := &Workspace{Root: filepath.Dir(path)}
+	names := make(map[string]string)
+	for _, p := range file.Projects {
+		p = filepath.Clean(p)
+		if filepath.IsAbs(p) || p == ".." || strings.HasPrefix(p, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("%s: project %s should be a folder of the workspace", path, p)
+		}
+		root := filepath.Join(ws.Root, p)
+		data, err := os.ReadFile(filepath.Join(root, projectYml))
+		if err != nil {
+			return nil, fmt.Errorf("%s: project %s: %w", path, p, err)
+		}
+		cfg := ProjectConfig{}
+		if err = yaml.Unmarshal(data, &cfg); err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Join(root, projectYml), err)
+		}
+		if other, ok := names[cfg.Name]; ok {
+			return nil, fmt.Errorf("%s: projects %s and %s are both named %q", path, other, p, cfg.Name)
+		}
+		names[cfg.Name] = p
+		ws.Projects = append(ws.Projects, WorkspaceProject{Name: cfg.Name, Path: p, Root: root})
+	}
+	return ws, nil
+}
+
+// Project returns the project of the workspace with the given name or path.
+func (ws *Workspace) Project(name string) (WorkspaceProject, error) {
+	for _, p := range ws.Projects {
+		if p.Name == name || p.Path == filepath.Clean(name) {
+			return p, nil
+		}
+	}
+	return WorkspaceProject{}, fmt.Errorf("no project %s in %s, the projects are: %s", name, filepath.Join(ws.Root, workspaceYml), strings.Join(ws.names(), ", "))
+}
+
+func (ws *Workspace) names() []string {
+	names := make([]string, 0, len(ws.Projects))
+	for _, p := range ws.Projects {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// repoPath returns the path of dir relative to the root of its git
+// repository, or to the workspace if it is not in one.
+func repoPath(dir string, ws *Workspace) (string, error) {
+	for repo := dir; ; {
+		if _, err := os.Stat(filepath.Join(repo, ".git")); err == nil {
+			return relSlash(repo, dir)
+		}
+		parent := filepath.Dir(repo)
+		if parent == repo {
+			break
+		}
+		repo = parent
+	}
+	if ws != nil {
+		return relSlash(ws.Root, dir)
+	}
+	return "", nil
+}
+
+func relSlash(base, dir string) (string, error) {
+	rel, err := filepath.Rel(base, dir)
+	if err != nil {
+		return "", err
+	}
+	if rel == "." {
+		return "", nil
+	}
+	return filepath.ToSlash(rel), nil
+}
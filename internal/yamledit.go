@@ -0,0 +1,71 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// editYAML rewrites a YAML file with what edit returns for its content and
+// document. Edits are made on the text, to keep comments and formatting.
+func editYAML(path string, edit func(data []byte, doc *yaml.Node) ([]byte, error)) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	doc := &yaml.Node{}
+	if err = yaml.Unmarshal(data, doc); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	edited, err := edit(data, doc)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, edited, info.Mode())
+}
+
+// mappingValue returns the value of key in the top-level mapping of doc.
+func mappingValue(doc *yaml.Node, key string) *yaml.Node {
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		doc = doc.Content[0]
+	}
+	if doc.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		if doc.Content[i].Value == key {
+			return doc.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// replaceScalar replaces the single-line scalar node in data with value,
+// keeping its quotes.
+func replaceScalar(data []byte, node *yaml.Node, value string) []byte {
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	line := lines[node.Line-1]
+	start := node.Column - 1
+	end := start + len(node.Value)
+	if node.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0 {
+		start++
+		end++
+	}
+	edited := append([]byte{}, line[:start]...)
+	edited = append(edited, value...)
+	edited = append(edited, line[end:]...)
+	lines[node.Line-1] = edited
+	return bytes.Join(lines, nil)
+}
+
+// removeLine removes line n, counted from 1, of data.
+func removeLine(data []byte, n int) []byte {
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	return bytes.Join(append(lines[:n-1], lines[n:]...), nil)
+}